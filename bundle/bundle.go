@@ -0,0 +1,451 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bundle packages generated files into tar.gz or zip archives together with a manifest describing where
+// they come from. Manifest may be signed with ed25519 key, so a bundle can be verified before a rollout
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	xed25519 "golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// ManifestName and SignatureName are names of a manifest and its signature in a bundle
+	ManifestName  = "MANIFEST.json"
+	SignatureName = "MANIFEST.json.sig"
+	// FilesDir is a directory of a bundle with generated files
+	FilesDir = "files/"
+)
+
+// Formats of bundles
+const (
+	FormatTarGz = "tar.gz"
+	FormatZip   = "zip"
+)
+
+// File describes a single generated file of a bundle
+type File struct {
+	// Path is relative to output directory
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	// Rows are numbers of data file rows (starting with 1) a file was generated from, files generated once
+	// for the whole data file have none
+	Rows     []int  `json:"rows,omitempty"`
+	Template string `json:"template,omitempty"`
+	// Bases are names of templates a template extends, starting with the most basic one
+	Bases []string `json:"bases,omitempty"`
+	// TemplateSHA256 is a checksum of contents of base templates and a template itself, joined in order of Bases
+	TemplateSHA256 string `json:"template_sha256,omitempty"`
+}
+
+// Manifest describes a generation and all files of a bundle
+type Manifest struct {
+	Version    string    `json:"version"`
+	Workspace  string    `json:"workspace"`
+	Generated  time.Time `json:"generated"`
+	Commit     string    `json:"commit,omitempty"`
+	DataFile   string    `json:"data_file"`
+	DataSHA256 string    `json:"data_sha256"`
+	Files      []File    `json:"files"`
+}
+
+// Sum returns SHA-256 checksum of content as a hex string
+func Sum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// FormatOf returns a format of a bundle based on its name: tar.gz (.tar.gz or .tgz) or zip (.zip)
+func FormatOf(name string) (string, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return FormatTarGz, nil
+	case strings.HasSuffix(lower, ".zip"):
+		return FormatZip, nil
+	}
+
+	return "", fmt.Errorf("unknown format of bundle %s, expected .tar.gz, .tgz or .zip", name)
+}
+
+// Create writes a bundle of a given format with files and their manifest. Checksums of files are set in the manifest
+// and the manifest is signed when a key isn't nil. Entries are sorted and dated with the time of the generation,
+// so the same files make the same bundle
+func Create(w io.Writer, format string, manifest Manifest, contents map[string][]byte, key ed25519.PrivateKey) error {
+	files := append([]File{}, manifest.Files...)
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	for i, f := range files {
+		content, ok := contents[f.Path]
+		if !ok {
+			return fmt.Errorf("no content of file %s", f.Path)
+		}
+		files[i].SHA256 = Sum(content)
+	}
+	manifest.Files = files
+
+	m, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	m = append(m, '\n')
+
+	entries := []entry{{ManifestName, m}}
+	if key != nil {
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, m))
+		entries = append(entries, entry{SignatureName, []byte(sig + "\n")})
+	}
+	for _, f := range files {
+		entries = append(entries, entry{FilesDir + f.Path, contents[f.Path]})
+	}
+
+	switch format {
+	case FormatTarGz:
+		return writeTarGz(w, entries, manifest.Generated)
+	case FormatZip:
+		return writeZip(w, entries, manifest.Generated)
+	}
+
+	return fmt.Errorf("unknown format of bundle '%s', expected one of: %s, %s", format, FormatTarGz, FormatZip)
+}
+
+// entry is a single file of an archive
+type entry struct {
+	name    string
+	content []byte
+}
+
+func writeTarGz(w io.Writer, entries []entry, modTime time.Time) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, e := range entries {
+		err := tw.WriteHeader(&tar.Header{
+			Name:    e.name,
+			Mode:    0644,
+			Size:    int64(len(e.content)),
+			ModTime: modTime,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(e.content)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := tw.Close()
+	if err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+func writeZip(w io.Writer, entries []entry, modTime time.Time) error {
+	zw := zip.NewWriter(w)
+
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		header.SetModTime(modTime)
+
+		f, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(e.content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// Bundle is a bundle read from an archive
+type Bundle struct {
+	Manifest Manifest
+	// manifest holds the manifest as it is written in a bundle, as it is what the signature is made of
+	manifest []byte
+	// Signature is nil when a bundle isn't signed
+	Signature []byte
+	// Files holds content of all files of a bundle (other than the manifest and its signature) by their paths
+	// relative to files directory
+	Files map[string][]byte
+}
+
+// Open reads a bundle from a file, its format is based on a name of a file
+func Open(name string) (*Bundle, error) {
+	format, err := FormatOf(name)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return Read(b, format)
+}
+
+// Read reads a bundle of a given format
+func Read(archive []byte, format string) (*Bundle, error) {
+	var entries []entry
+	var err error
+
+	switch format {
+	case FormatTarGz:
+		entries, err = readTarGz(archive)
+	case FormatZip:
+		entries, err = readZip(archive)
+	default:
+		err = fmt.Errorf("unknown format of bundle '%s', expected one of: %s, %s", format, FormatTarGz, FormatZip)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bundle{Files: make(map[string][]byte)}
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		// an archive may hold several entries of the same name, only one of them would be checked
+		if seen[e.name] {
+			return nil, fmt.Errorf("duplicate file %s in bundle", e.name)
+		}
+		seen[e.name] = true
+
+		switch {
+		case e.name == ManifestName:
+			b.manifest = e.content
+		case e.name == SignatureName:
+			b.Signature, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(e.content)))
+			if err != nil {
+				return nil, fmt.Errorf("invalid signature: %s", err)
+			}
+		case strings.HasPrefix(e.name, FilesDir):
+			b.Files[strings.TrimPrefix(e.name, FilesDir)] = e.content
+		default:
+			return nil, fmt.Errorf("unexpected file %s in bundle", e.name)
+		}
+	}
+
+	if b.manifest == nil {
+		return nil, fmt.Errorf("no %s in bundle", ManifestName)
+	}
+	err = json.Unmarshal(b.manifest, &b.Manifest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", ManifestName, err)
+	}
+
+	return b, nil
+}
+
+func readTarGz(archive []byte) ([]entry, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var entries []entry
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{header.Name, content})
+	}
+}
+
+func readZip(archive []byte) ([]entry, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{f.Name, content})
+	}
+
+	return entries, nil
+}
+
+// Verify checks files of a bundle against checksums of its manifest and returns all problems found. Files missing
+// from the manifest are problems too. Signature of the manifest is checked when a key isn't nil
+func (b *Bundle) Verify(key ed25519.PublicKey) []error {
+	var problems []error
+
+	if key != nil {
+		switch {
+		case b.Signature == nil:
+			problems = append(problems, fmt.Errorf("bundle isn't signed"))
+		case !ed25519.Verify(key, b.manifest, b.Signature):
+			problems = append(problems, fmt.Errorf("signature of %s doesn't match the key", ManifestName))
+		}
+	}
+
+	listed := make(map[string]bool)
+	for _, f := range b.Manifest.Files {
+		listed[f.Path] = true
+
+		content, ok := b.Files[f.Path]
+		if !ok {
+			problems = append(problems, fmt.Errorf("%s: missing from bundle", f.Path))
+			continue
+		}
+		if sum := Sum(content); sum != f.SHA256 {
+			problems = append(problems, fmt.Errorf("%s: checksum %s doesn't match %s of the manifest", f.Path, sum, f.SHA256))
+		}
+	}
+
+	var extra []string
+	for path := range b.Files {
+		if !listed[path] {
+			extra = append(extra, path)
+		}
+	}
+	sort.Strings(extra)
+	for _, path := range extra {
+		problems = append(problems, fmt.Errorf("%s: not listed in the manifest", path))
+	}
+
+	return problems
+}
+
+// ParsePrivateKey parses unencrypted ed25519 private key in OpenSSH format (as written by 'ssh-keygen -t ed25519')
+func ParsePrivateKey(b []byte) (ed25519.PrivateKey, error) {
+	key, err := ssh.ParseRawPrivateKey(b)
+	if err != nil {
+		return nil, err
+	}
+
+	// x/crypto/ssh returns keys of its own ed25519 package unless it's an alias of crypto/ed25519
+	if k, ok := key.(*ed25519.PrivateKey); ok {
+		return *k, nil
+	}
+	if k, ok := key.(*xed25519.PrivateKey); ok {
+		return ed25519.PrivateKey(*k), nil
+	}
+
+	return nil, fmt.Errorf("key isn't an ed25519 key")
+}
+
+// ParsePublicKey parses ed25519 public key in authorized_keys format (e.g. id_ed25519.pub)
+func ParsePublicKey(b []byte) (ed25519.PublicKey, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if k, ok := key.(ssh.CryptoPublicKey); ok {
+		if pub, ok := k.CryptoPublicKey().(ed25519.PublicKey); ok {
+			return pub, nil
+		}
+		if pub, ok := k.CryptoPublicKey().(xed25519.PublicKey); ok {
+			return ed25519.PublicKey(pub), nil
+		}
+	}
+
+	return nil, fmt.Errorf("key isn't an ed25519 key")
+}
+
+// ReadPrivateKey reads ed25519 private key in OpenSSH format from a file
+func ReadPrivateKey(name string) (ed25519.PrivateKey, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ParsePrivateKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("can't read private key %s: %s", name, err)
+	}
+
+	return key, nil
+}
+
+// ReadPublicKey reads ed25519 public key in authorized_keys format from a file
+func ReadPublicKey(name string) (ed25519.PublicKey, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ParsePublicKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("can't read public key %s: %s", name, err)
+	}
+
+	return key, nil
+}
+
+// WriteFile creates a bundle file, its format is based on a name of a file
+func WriteFile(name string, manifest Manifest, contents map[string][]byte, key ed25519.PrivateKey) error {
+	format, err := FormatOf(name)
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	err = Create(&b, format, manifest, contents, key)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(name, b.Bytes(), 0644)
+}
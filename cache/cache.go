@@ -0,0 +1,123 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache records checksums of inputs output files were generated from, so only files with changed inputs
+// are generated again
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+)
+
+// Entry describes inputs and content of a single output file
+type Entry struct {
+	// Key is a checksum of inputs of a file: rows, templates, variables, settings and go-tmpl version
+	Key string `json:"key"`
+	// DataSHA256 is a checksum of a data file, it is set only for files which depend on all its rows
+	// (e.g. generated with lookups)
+	DataSHA256 string `json:"data_sha256,omitempty"`
+	// Meta is a checksum of information about the generation (.Meta), it is set only for files which refer to it
+	Meta string `json:"meta,omitempty"`
+	// SHA256 is a checksum of a generated file, so changed or removed files are generated again
+	SHA256 string `json:"sha256"`
+}
+
+// Cache holds entries of output files by their paths
+type Cache struct {
+	Outputs map[string]Entry `json:"outputs"`
+	path    string
+}
+
+// New returns an empty cache saved to a given file
+func New(path string) *Cache {
+	return &Cache{Outputs: make(map[string]Entry), path: path}
+}
+
+// Load reads a cache from a file. A cache is empty when there is no such file. An empty cache is returned
+// together with an error when the file is invalid, so all outputs are generated again
+func Load(path string) (*Cache, error) {
+	c := New(path)
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+
+	err = json.Unmarshal(b, c)
+	if err != nil || c.Outputs == nil {
+		return New(path), fmt.Errorf("invalid cache %s, it is ignored", path)
+	}
+
+	return c, nil
+}
+
+// Sum returns SHA-256 checksum of content as a hex string
+func Sum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Key returns a checksum of given inputs. Inputs are prefixed with their lengths, so different inputs
+// can't make the same key when they are joined
+func Key(inputs ...string) string {
+	h := sha256.New()
+	for _, input := range inputs {
+		h.Write([]byte(strconv.Itoa(len(input)) + ":" + input))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Fresh reports whether an output file with a given checksum (empty when it doesn't exist) is generated from inputs
+// with a given key. Files depending on all rows of a data file are fresh as long as the data file isn't changed
+// and files referring to information about the generation as long as it isn't changed
+func (c *Cache) Fresh(name string, key string, dataSHA256 string, meta string, sum string) bool {
+	e, ok := c.Outputs[name]
+	if !ok || sum == "" {
+		return false
+	}
+
+	return e.Key == key && e.SHA256 == sum && (e.DataSHA256 == "" || e.DataSHA256 == dataSHA256) &&
+		(e.Meta == "" || e.Meta == meta)
+}
+
+// Put records inputs and a checksum of a generated file, dataSHA256 should be empty when a file doesn't depend
+// on all rows of a data file and meta should be empty when a file doesn't refer to information about the generation
+func (c *Cache) Put(name string, key string, dataSHA256 string, meta string, sum string) {
+	c.Outputs[name] = Entry{Key: key, DataSHA256: dataSHA256, Meta: meta, SHA256: sum}
+}
+
+// Delete removes an entry of a file which isn't generated anymore
+func (c *Cache) Delete(name string) {
+	delete(c.Outputs, name)
+}
+
+// Save writes a cache to its file
+func (c *Cache) Save() error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.path, append(b, '\n'), 0644)
+}
@@ -0,0 +1,104 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	if Key("ab", "c") == Key("a", "bc") {
+		t.Errorf("expected to get different keys for different inputs")
+	}
+	if Key("ab", "c") != Key("ab", "c") {
+		t.Errorf("expected to get the same key for the same inputs")
+	}
+}
+
+func TestFresh(t *testing.T) {
+	c := New("")
+	c.Put("r1.cfg", "k1", "", "", "s1")
+	c.Put("bgp.cfg", "k2", "d1", "", "s2")
+	c.Put("r3.cfg", "k1", "", "", "s1")
+	c.Put("motd.cfg", "k1", "", "m1", "s4")
+	c.Delete("r3.cfg")
+
+	var testCases = []struct {
+		name     string
+		key      string
+		data     string
+		meta     string
+		sum      string
+		expected bool
+	}{
+		{"r1.cfg", "k1", "d1", "m1", "s1", true},
+		{"r1.cfg", "k1", "d2", "m2", "s1", true},
+		{"r1.cfg", "k3", "d1", "m1", "s1", false},
+		{"r1.cfg", "k1", "d1", "m1", "s3", false},
+		{"r1.cfg", "k1", "d1", "m1", "", false},
+		{"bgp.cfg", "k2", "d1", "m1", "s2", true},
+		{"bgp.cfg", "k2", "d2", "m1", "s2", false},
+		{"motd.cfg", "k1", "d2", "m1", "s4", true},
+		{"motd.cfg", "k1", "d1", "m2", "s4", false},
+		{"r2.cfg", "k1", "d1", "m1", "s1", false},
+		{"r3.cfg", "k1", "d1", "m1", "s1", false},
+	}
+
+	for _, tc := range testCases {
+		if got := c.Fresh(tc.name, tc.key, tc.data, tc.meta, tc.sum); got != tc.expected {
+			t.Errorf("expected freshness of %s (key %s, data %s, meta %s, sum %s) to be %t, instead got %t", tc.name, tc.key, tc.data, tc.meta, tc.sum, tc.expected, got)
+		}
+	}
+}
+
+func TestLoadSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cache.json")
+
+	c, err := Load(path)
+	if err != nil || len(c.Outputs) != 0 {
+		t.Fatalf("expected to get an empty cache without a file, instead got %v (error: %v)", c.Outputs, err)
+	}
+
+	c.Put("r1.cfg", "k1", "", "", "s1")
+	err = c.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Fresh("r1.cfg", "k1", "", "", "s1") {
+		t.Errorf("expected to get saved entry, instead got %v", c.Outputs)
+	}
+
+	err = ioutil.WriteFile(path, []byte("{invalid"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err = Load(path)
+	if err == nil || len(c.Outputs) != 0 {
+		t.Errorf("expected to get an empty cache and an error for invalid file, instead got %v (error: %v)", c.Outputs, err)
+	}
+}
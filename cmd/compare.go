@@ -0,0 +1,166 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pegaz/go-tmpl/conftree"
+	"github.com/spf13/cobra"
+)
+
+var remediation bool
+
+// compareCmd represents the compare command
+var compareCmd = &cobra.Command{
+	Use:   "compare [file...]",
+	Short: "Compare generated files with running configs",
+	Long: `Compare generated files from output directory with running configs captured in running directory of a workspace.
+Without arguments files generated from templates for rows of the data file are compared. Configs are compared by
+sections regardless of their order and whitespaces. Running config is looked up by a path of a generated file or by
+its name without extension, e.g. 'running/r1.txt' for 'output/waw/r1.cfg'.`,
+
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputDir := rootDir + "/" + workspaceName + directories["output"]
+		runningDir := rootDir + "/" + workspaceName + directories["running"]
+
+		files := args
+		if len(files) == 0 {
+			setDefaults()
+
+			err := initConfig()
+			if err != nil {
+				return err
+			}
+
+			wd, err := loadData()
+			if err != nil {
+				return err
+			}
+
+			files, err = generatedFiles(wd, outputDir)
+			if err != nil {
+				return err
+			}
+		}
+
+		var differ int
+		for _, name := range files {
+			generated, err := ioutil.ReadFile(filepath.Join(outputDir, name))
+			if err != nil {
+				return err
+			}
+
+			runningPath := runningConfig(runningDir, name)
+			if runningPath == "" {
+				fmt.Printf("=== %s: no running config, skipped\n", name)
+				continue
+			}
+			running, err := ioutil.ReadFile(runningPath)
+			if err != nil {
+				return err
+			}
+
+			changes := conftree.Diff(conftree.Parse(string(generated)), conftree.Parse(string(running)))
+			if len(changes) == 0 {
+				continue
+			}
+			differ++
+
+			fmt.Printf("=== %s\n", name)
+			lines := conftree.Report(changes)
+			if remediation {
+				lines = conftree.Remediation(changes)
+			}
+			fmt.Println(strings.Join(lines, "\n"))
+			fmt.Println()
+		}
+
+		if differ > 0 {
+			fmt.Printf("%d of %d files differ from running configs", differ, len(files))
+		} else {
+			fmt.Print("No differences")
+		}
+
+		return nil
+	},
+}
+
+// generatedFiles returns paths (relative to output directory) of files generated from templates for rows of a data
+// file, in order of rows. Aggregate outputs, DHCP reservations and other files of output directory aren't device
+// configs, so they are left out. Rows appending to the same file are compared once
+func generatedFiles(wd *workspaceData, outputDir string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+
+	for i, row := range wd.raw {
+		if row[templateColumnName] == "" || row[outputColumnName] == "" {
+			continue
+		}
+
+		_, outputName, err := wd.rowTemplate(i)
+		if err != nil {
+			return nil, err
+		}
+		if seen[outputName] {
+			continue
+		}
+		seen[outputName] = true
+
+		if _, err := os.Stat(filepath.Join(outputDir, outputName)); os.IsNotExist(err) {
+			fmt.Printf("warning: compare: %s isn't generated, skipped\n", outputName)
+			continue
+		}
+		files = append(files, outputName)
+	}
+
+	return files, nil
+}
+
+// runningConfig returns path of a running config captured for a generated file: the one with the same path relative
+// to running directory or the first one with the same name without extension. Empty string is returned when there is none
+func runningConfig(runningDir string, name string) string {
+	path := filepath.Join(runningDir, name)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+
+	base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	matches, _ := filepath.Glob(filepath.Join(runningDir, base+".*"))
+	if len(matches) > 0 {
+		return matches[0]
+	}
+	if _, err := os.Stat(filepath.Join(runningDir, base)); err == nil {
+		return filepath.Join(runningDir, base)
+	}
+
+	return ""
+}
+
+func init() {
+	compareCmd.Flags().StringVarP(&workspaceName, "name", "n", "", "workspace to compare files of")
+	compareCmd.MarkFlagRequired("name")
+	compareCmd.Flags().StringVarP(&workspaceConfig, "config", "c", "workspace.toml", "configuration file to use generator for")
+	compareCmd.Flags().StringSliceVar(&profiles, "profile", nil, "profiles of configuration file to apply, in order")
+	compareCmd.Flags().BoolVar(&remediation, "remediation", false, "print commands turning running configs into generated ones instead of differences")
+
+	rootCmd.AddCommand(compareCmd)
+}
@@ -0,0 +1,62 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestGeneratedFiles(t *testing.T) {
+	defer setupWorkspace(t, map[string]string{
+		"workspace.toml": "template_column_name = \"router\"\noutput_column_name = \"hostname\"\n" +
+			"[dhcp]\nkea = \"dhcp/kea.json\"\n[[aggregate]]\ntemplate = \"hosts\"\noutput = \"hosts.txt\"\n",
+		"data/data.csv":       "hostname,router,mac,ip\nr1,ios,00:50:56:aa:bb:cc,10.0.0.1\nr2,ios,,\nr1,ios,,\n",
+		"templates/ios.tpl":   "{{/*---\npath: \"waw/{{.hostname}}\"\n---*/}}\nhostname {{.hostname}}\n",
+		"templates/hosts.tpl": "{{range .Rows}}{{.hostname}}\n{{end}}",
+		"output/README.md":    "output files\n",
+	})()
+
+	if err := generateCmd.RunE(generateCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// stray files and rows added since the last generation aren't compared
+	writeFiles(t, map[string]string{
+		"output/notes.txt": "notes\n",
+		"data/data.csv":    "hostname,router,mac,ip\nr1,ios,00:50:56:aa:bb:cc,10.0.0.1\nr2,ios,,\nr1,ios,,\nr3,ios,,\n",
+	})
+	viper.Reset()
+	setDefaults()
+	if err := initConfig(); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := loadData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := generatedFiles(wd, rootDir+"/"+workspaceName+directories["output"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"waw/r1.txt", "waw/r2.txt"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("expected to get %v, instead got %v", expected, files)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pegaz/go-tmpl/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect configuration of a workspace",
+}
+
+// configShowCmd represents the config show command
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration with the origin of each value",
+	Long: `Print the effective configuration of a workspace: configuration file merged with base files it extends,
+profiles given with --profile and global variables set with GOTMPL_VAR_<name> environment variables. Every value
+is followed by its origin, values which aren't set anywhere are defaults of go-tmpl.`,
+
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		setDefaults()
+
+		err := initConfig()
+		if err != nil {
+			return err
+		}
+
+		keys := viper.AllKeys()
+		sort.Strings(keys)
+
+		var lines [][2]string
+		var width int
+		for _, key := range keys {
+			line := [2]string{key + " = " + config.Format(viper.Get(key)), "default"}
+			if origin, ok := workspaceSettings.Origins[key]; ok {
+				line[1] = origin
+			}
+			lines = append(lines, line)
+
+			if len(line[0]) > width {
+				width = len(line[0])
+			}
+		}
+
+		for _, line := range lines {
+			fmt.Printf("%-*s  # %s\n", width, line[0], line[1])
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	configShowCmd.Flags().StringVarP(&workspaceName, "name", "n", "", "workspace to show configuration of")
+	configShowCmd.MarkFlagRequired("name")
+	configShowCmd.Flags().StringVarP(&workspaceConfig, "config", "c", "workspace.toml", "configuration file to show")
+	configShowCmd.Flags().StringSliceVar(&profiles, "profile", nil, "profiles of configuration file to apply, in order")
+
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
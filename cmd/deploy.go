@@ -0,0 +1,328 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pegaz/go-tmpl/deploy"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	DefaultDeployConcurrency = 5
+	// PasswordEnv names an environment variable with SSH password, so it isn't kept in configuration file
+	PasswordEnv = "GOTMPL_SSH_PASSWORD"
+)
+
+var (
+	confirmDeploy     bool
+	deployConcurrency int
+)
+
+// deploySettings describes how generated files are pushed to devices
+type deploySettings struct {
+	// HostColumn names a column with a hostname or an IP address of a device
+	HostColumn string `mapstructure:"host_column"`
+	Port       int
+	User       string
+	KeyFile    string `mapstructure:"key_file"`
+	KnownHosts string `mapstructure:"known_hosts"`
+	// InsecureHostKey disables checking of host keys, e.g. in a lab
+	InsecureHostKey bool `mapstructure:"insecure_host_key"`
+	Method          string
+	RemotePath      string `mapstructure:"remote_path"`
+	Before          []string
+	After           []string
+	Concurrency     int
+	Timeout         string
+	LineDelay       string `mapstructure:"line_delay"`
+}
+
+var deployConfig deploySettings
+
+// deployCmd represents the deploy command
+var deployCmd = &cobra.Command{
+	Use:   "deploy [host...]",
+	Short: "Push generated files to devices over SSH",
+	Long: `Push generated files to devices over SSH. Output file of every row of a data file is pushed to a device named in
+a host column, either uploaded with SCP or pasted line by line into an interactive session. Transcripts of sessions
+are saved in logs directory of a workspace. Devices may be limited to given hosts or output files. Without --confirm
+files which would be pushed are listed only.`,
+
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		setDefaults()
+
+		err := initConfig()
+		if err != nil {
+			return err
+		}
+		err = initDeployConfig()
+		if err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("concurrency") {
+			deployConcurrency = deployConfig.Concurrency
+		}
+
+		wd, err := loadData()
+		if err != nil {
+			return err
+		}
+
+		jobs, err := deployJobs(wd, args)
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			fmt.Print("Nothing to do")
+			return nil
+		}
+
+		if !confirmDeploy {
+			for _, job := range jobs {
+				fmt.Printf("* %s <- %s\n", job.Addr, job.Name)
+			}
+			fmt.Println()
+			fmt.Printf("%d files would be pushed, run with --confirm to push them", len(jobs))
+			return nil
+		}
+
+		opts, err := deployOptions()
+		if err != nil {
+			return err
+		}
+
+		logsDir := rootDir + "/" + workspaceName + directories["logs"]
+		err = os.MkdirAll(logsDir, 0755)
+		if err != nil {
+			return err
+		}
+
+		started := time.Now()
+		var logs []*os.File
+		for i, job := range jobs {
+			name := strings.NewReplacer(":", "_", "/", "_", "\\", "_").Replace(job.Addr + "-" + job.Name)
+			f, err := os.Create(filepath.Join(logsDir, name+"-"+started.Format("20060102-150405")+".log"))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			fmt.Fprintf(f, "# %s <- %s (%s, %s)\n", job.Addr, job.Name, deployConfig.Method, started.Format(time.RFC3339))
+
+			jobs[i].Transcript = f
+			logs = append(logs, f)
+		}
+
+		errs := deploy.Run(jobs, opts, deployConcurrency)
+
+		var failed int
+		for i, err := range errs {
+			if err != nil {
+				failed++
+				fmt.Fprintf(logs[i], "\n# failed: %s\n", err)
+				fmt.Printf("* %s <- %s: failed: %s\n", jobs[i].Addr, jobs[i].Name, err)
+				continue
+			}
+			fmt.Printf("* %s <- %s: ok\n", jobs[i].Addr, jobs[i].Name)
+		}
+		fmt.Println()
+
+		if failed > 0 {
+			return fmt.Errorf("pushing %d of %d files failed, transcripts are in %s", failed, len(jobs), logsDir)
+		}
+		fmt.Printf("Succesfully pushed %d files", len(jobs))
+
+		return nil
+	},
+}
+
+// initDeployConfig reads and checks [deploy] section of configuration file
+func initDeployConfig() error {
+	deployConfig = deploySettings{
+		HostColumn:  outputColumnName,
+		Port:        22,
+		Method:      deploy.MethodSCP,
+		Concurrency: DefaultDeployConcurrency,
+	}
+
+	err := viper.UnmarshalKey("deploy", &deployConfig)
+	if err != nil {
+		return fmt.Errorf("invalid 'deploy' section of configuration file: %s", err)
+	}
+
+	if deployConfig.Method != deploy.MethodSCP && deployConfig.Method != deploy.MethodPaste {
+		return fmt.Errorf("invalid 'deploy' section of configuration file: unknown method '%s', expected one of: %s, %s",
+			deployConfig.Method, deploy.MethodSCP, deploy.MethodPaste)
+	}
+	if deployConfig.Port < 1 || deployConfig.Port > 65535 {
+		return fmt.Errorf("invalid 'deploy' section of configuration file: invalid port %d", deployConfig.Port)
+	}
+	if deployConfig.Concurrency < 1 {
+		return fmt.Errorf("invalid 'deploy' section of configuration file: concurrency should be at least 1")
+	}
+	for _, d := range []string{deployConfig.Timeout, deployConfig.LineDelay} {
+		if _, err = parseDuration(d); err != nil {
+			return fmt.Errorf("invalid 'deploy' section of configuration file: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// parseDuration parses a duration from configuration file, e.g. '30s', empty one is zero
+func parseDuration(d string) (time.Duration, error) {
+	if d == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(d)
+}
+
+// deployJobs returns a job for every output file of rows with a host, limited to given hosts or output files. Rows
+// appending to the same file of the same host are pushed once
+func deployJobs(wd *workspaceData, only []string) ([]deploy.Job, error) {
+	var jobs []deploy.Job
+	seen := make(map[string]bool)
+
+	for i, row := range wd.raw {
+		if row[templateColumnName] == "" || row[outputColumnName] == "" {
+			continue
+		}
+
+		host := strings.TrimSpace(row[deployConfig.HostColumn])
+		if host == "" {
+			fmt.Printf("warning: deploy: row %d has no '%s' value, skipped\n", i+1, deployConfig.HostColumn)
+			continue
+		}
+		// addresses may be given with a prefix length, e.g. 10.0.0.1/24
+		host = strings.Split(host, "/")[0]
+
+		_, outputName, err := wd.rowTemplate(i)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(only) > 0 && !contains(only, host) && !contains(only, row[outputColumnName]) && !contains(only, outputName) {
+			continue
+		}
+
+		addr := host
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			addr = net.JoinHostPort(host, fmt.Sprint(deployConfig.Port))
+		}
+		if seen[addr+" "+outputName] {
+			continue
+		}
+		seen[addr+" "+outputName] = true
+
+		content, err := ioutil.ReadFile(rootDir + "/" + workspaceName + directories["output"] + "/" + outputName)
+		if os.IsNotExist(err) {
+			fmt.Printf("warning: deploy: %s isn't generated, skipped\n", outputName)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, deploy.Job{Addr: addr, Name: outputName, Content: content})
+	}
+
+	return jobs, nil
+}
+
+// deployOptions returns options of pushing files with credentials from a key file and GOTMPL_SSH_PASSWORD environment
+// variable. Host keys are checked with known_hosts file (~/.ssh/known_hosts by default)
+func deployOptions() (deploy.Options, error) {
+	var auth []ssh.AuthMethod
+
+	if deployConfig.KeyFile != "" {
+		b, err := ioutil.ReadFile(deployConfig.KeyFile)
+		if err != nil {
+			return deploy.Options{}, err
+		}
+		signer, err := ssh.ParsePrivateKey(b)
+		if err != nil {
+			return deploy.Options{}, fmt.Errorf("can't read key file %s: %s", deployConfig.KeyFile, err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if password, ok := os.LookupEnv(PasswordEnv); ok {
+		auth = append(auth, ssh.Password(password))
+	}
+	if len(auth) == 0 {
+		return deploy.Options{}, fmt.Errorf("no SSH credentials, set 'key_file' in 'deploy' section of configuration file or %s environment variable", PasswordEnv)
+	}
+	if deployConfig.User == "" {
+		return deploy.Options{}, fmt.Errorf("no SSH user, set 'user' in 'deploy' section of configuration file")
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if !deployConfig.InsecureHostKey {
+		path := deployConfig.KnownHosts
+		if path == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return deploy.Options{}, err
+			}
+			path = filepath.Join(home, ".ssh", "known_hosts")
+		}
+
+		var err error
+		hostKeyCallback, err = knownhosts.New(path)
+		if err != nil {
+			return deploy.Options{}, fmt.Errorf("can't read known hosts: %s", err)
+		}
+	}
+
+	timeout, _ := parseDuration(deployConfig.Timeout)
+	lineDelay, _ := parseDuration(deployConfig.LineDelay)
+
+	return deploy.Options{
+		Method:     deployConfig.Method,
+		RemotePath: deployConfig.RemotePath,
+		Before:     deployConfig.Before,
+		After:      deployConfig.After,
+		LineDelay:  lineDelay,
+		Timeout:    timeout,
+		Config: &ssh.ClientConfig{
+			User:            deployConfig.User,
+			Auth:            auth,
+			HostKeyCallback: hostKeyCallback,
+		},
+	}, nil
+}
+
+func init() {
+	deployCmd.Flags().StringVarP(&workspaceName, "name", "n", "", "workspace to push files of")
+	deployCmd.MarkFlagRequired("name")
+	deployCmd.Flags().StringVarP(&workspaceConfig, "config", "c", "workspace.toml", "configuration file to use generator for")
+	deployCmd.Flags().StringSliceVar(&profiles, "profile", nil, "profiles of configuration file to apply, in order")
+	deployCmd.Flags().BoolVar(&confirmDeploy, "confirm", false, "push files to devices, without it files are listed only")
+	deployCmd.Flags().IntVar(&deployConcurrency, "concurrency", DefaultDeployConcurrency, "maximum number of devices pushed to at once")
+
+	rootCmd.AddCommand(deployCmd)
+}
@@ -0,0 +1,157 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pegaz/go-tmpl/dhcp"
+	"github.com/pegaz/go-tmpl/text"
+	"github.com/spf13/viper"
+)
+
+// dhcpSettings describes DHCP reservations generated from a data file, which point devices to their output files
+type dhcpSettings struct {
+	// Kea and ISC are paths of output files (relative to output directory) of reservations in a given format
+	Kea            string
+	ISC            string
+	HostnameColumn string `mapstructure:"hostname_column"`
+	MACColumn      string `mapstructure:"mac_column"`
+	IPColumn       string `mapstructure:"ip_column"`
+	TFTPServer     string `mapstructure:"tftp_server"`
+	// BootfilePrefix is prepended to paths of output files in option 67, e.g. 'http://10.0.0.10:8080/'
+	BootfilePrefix string `mapstructure:"bootfile_prefix"`
+}
+
+var dhcpConfig dhcpSettings
+
+// initDHCPConfig reads and checks [dhcp] section of configuration file
+func initDHCPConfig() error {
+	dhcpConfig = dhcpSettings{
+		HostnameColumn: outputColumnName,
+		MACColumn:      "mac",
+		IPColumn:       "ip",
+	}
+
+	err := viper.UnmarshalKey("dhcp", &dhcpConfig)
+	if err != nil {
+		return fmt.Errorf("invalid 'dhcp' section of configuration file: %s", err)
+	}
+
+	err = dhcp.Options{TFTPServer: dhcpConfig.TFTPServer}.Check()
+	if err != nil {
+		return fmt.Errorf("invalid 'dhcp' section of configuration file: %s", err)
+	}
+
+	for _, output := range []string{dhcpConfig.Kea, dhcpConfig.ISC} {
+		if output == "" {
+			continue
+		}
+		if _, err = checkOutputName(output); err != nil {
+			return fmt.Errorf("invalid 'dhcp' section of configuration file: %s", err)
+		}
+	}
+	if dhcpConfig.Kea != "" && dhcpConfig.ISC != "" {
+		kea, _ := checkOutputName(dhcpConfig.Kea)
+		isc, _ := checkOutputName(dhcpConfig.ISC)
+		if kea == isc {
+			return fmt.Errorf("invalid 'dhcp' section of configuration file: 'kea' and 'isc' are the same file %s", kea)
+		}
+	}
+
+	return nil
+}
+
+// generateDHCP writes DHCP reservations of all rows with a hostname, MAC and IP address. Rows without any of them are skipped
+func generateDHCP(wd *workspaceData, rowOutputs []string) error {
+	var hosts []dhcp.Host
+
+	for i, row := range wd.raw {
+		hostname := strings.TrimSpace(row[dhcpConfig.HostnameColumn])
+		mac, ip := strings.TrimSpace(row[dhcpConfig.MACColumn]), strings.TrimSpace(row[dhcpConfig.IPColumn])
+		if hostname == "" || mac == "" || ip == "" {
+			fmt.Printf("warning: dhcp: row %d has no '%s', '%s' or '%s' value, skipped\n", i+1,
+				dhcpConfig.HostnameColumn, dhcpConfig.MACColumn, dhcpConfig.IPColumn)
+			continue
+		}
+
+		mac, err := text.MAC(mac)
+		if err != nil {
+			return fmt.Errorf("dhcp: row %d: %s", i+1, err)
+		}
+
+		host := dhcp.Host{
+			Hostname: hostname,
+			MAC:      mac,
+			// addresses may be given with a prefix length, e.g. 10.0.0.1/24
+			IP: strings.Split(ip, "/")[0],
+		}
+		if rowOutputs[i] != "" {
+			host.BootFile = dhcpConfig.BootfilePrefix + rowOutputs[i]
+		}
+		if err = host.Check(); err != nil {
+			return fmt.Errorf("dhcp: row %d: %s", i+1, err)
+		}
+
+		hosts = append(hosts, host)
+	}
+
+	opts := dhcp.Options{TFTPServer: dhcpConfig.TFTPServer}
+
+	if dhcpConfig.Kea != "" {
+		kea, err := dhcp.Kea(hosts, opts)
+		if err != nil {
+			return err
+		}
+
+		outputName, _ := checkOutputName(dhcpConfig.Kea)
+		err = writeDHCP(wd, outputName, kea, "json")
+		if err != nil {
+			return err
+		}
+	}
+
+	if dhcpConfig.ISC != "" {
+		isc, err := dhcp.ISC(hosts, opts)
+		if err != nil {
+			return err
+		}
+
+		outputName, _ := checkOutputName(dhcpConfig.ISC)
+		err = writeDHCP(wd, outputName, []byte(isc), "")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeDHCP writes reservations unless they are the same as the ones generated before. Reservations can't
+// replace a file generated from data file or by an aggregate template
+func writeDHCP(wd *workspaceData, outputName string, content []byte, linter string) error {
+	if source, ok := outputSources[outputName]; ok {
+		return fmt.Errorf("dhcp: reservations can't be written to %s, it is already generated from template %s", outputName, source.Template)
+	}
+
+	key := wd.key("dhcp", string(content))
+	if wd.fresh(outputName, key) {
+		return nil
+	}
+	addRendered(outputName, string(content), linter)
+
+	return writeOutput(outputName, content, key, "", "")
+}
@@ -0,0 +1,60 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/pegaz/go-tmpl/bundle"
+	"github.com/spf13/viper"
+)
+
+func TestInitDHCPConfig(t *testing.T) {
+	var testCases = []struct {
+		kea   string
+		isc   string
+		isErr bool
+	}{
+		{kea: "dhcp/kea.json", isc: "dhcp/dhcpd.conf"},
+		{kea: "dhcp/kea.json"},
+		{kea: "dhcp/hosts", isc: "./dhcp/hosts", isErr: true},
+		{isc: "../dhcpd.conf", isErr: true},
+	}
+
+	for _, tc := range testCases {
+		viper.Reset()
+		viper.Set("dhcp.kea", tc.kea)
+		viper.Set("dhcp.isc", tc.isc)
+
+		err := initDHCPConfig()
+		if tc.isErr && err == nil {
+			t.Errorf("expected to get an error for kea '%s' and isc '%s', instead got nil", tc.kea, tc.isc)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("expected to get no error for kea '%s' and isc '%s', instead got %s", tc.kea, tc.isc, err)
+		}
+	}
+	viper.Reset()
+}
+
+func TestWriteDHCPCollision(t *testing.T) {
+	outputSources["waw/r1.txt"] = &bundle.File{Path: "waw/r1.txt", Template: "ios", Rows: []int{1}}
+	defer delete(outputSources, "waw/r1.txt")
+
+	err := writeDHCP(&workspaceData{}, "waw/r1.txt", []byte("host r1 {\n}\n"), "")
+	if err == nil {
+		t.Error("expected to get an error for reservations written to an output file of a row, instead got nil")
+	}
+}
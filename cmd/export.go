@@ -0,0 +1,252 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pegaz/go-tmpl/inventory"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	inventoryFormat string
+	inventoryOutput string
+)
+
+// inventorySettings describes which columns of a data file make an inventory
+type inventorySettings struct {
+	// HostColumn and PlatformColumn name columns with an address and a network OS of a device
+	HostColumn     string   `mapstructure:"host_column"`
+	PlatformColumn string   `mapstructure:"platform_column"`
+	GroupColumns   []string `mapstructure:"group_columns"`
+	// VarColumns are columns exported as variables of hosts, all but template, output and reserved columns by default
+	VarColumns []string `mapstructure:"var_columns"`
+	// ConfigPrefix is prepended to paths of output files, absolute path of output directory by default
+	ConfigPrefix string `mapstructure:"config_prefix"`
+}
+
+var inventoryConfig inventorySettings
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export data of a workspace for other tools",
+}
+
+// exportInventoryCmd represents the export inventory command
+var exportInventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "Export devices as Ansible, Nornir or NAPALM inventory",
+	Long: `Export rows of a data file as an inventory of devices named by values of output column. Formats are:
+ansible (YAML), ansible-ini, nornir (hosts.yaml and groups.yaml written to --output directory) and napalm (JSON).
+Every device has config_file pointing to its output file. Inventory is printed when --output isn't given.`,
+
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		setDefaults()
+
+		err := initConfig()
+		if err != nil {
+			return err
+		}
+		err = initInventoryConfig()
+		if err != nil {
+			return err
+		}
+
+		wd, err := loadData()
+		if err != nil {
+			return err
+		}
+
+		hosts, err := inventoryHosts(wd)
+		if err != nil {
+			return err
+		}
+
+		var content []byte
+		switch inventoryFormat {
+		case "ansible":
+			content, err = inventory.AnsibleYAML(hosts)
+		case "ansible-ini":
+			var ini string
+			ini, err = inventory.AnsibleINI(hosts)
+			content = []byte(ini)
+		case "napalm":
+			content, err = inventory.NAPALM(hosts)
+		case "nornir":
+			return writeNornir(hosts)
+		default:
+			return fmt.Errorf("unknown format '%s', expected one of: ansible, ansible-ini, nornir, napalm", inventoryFormat)
+		}
+		if err != nil {
+			return err
+		}
+
+		if inventoryOutput == "" {
+			fmt.Print(string(content))
+			return nil
+		}
+
+		return ioutil.WriteFile(inventoryOutput, content, 0644)
+	},
+}
+
+// writeNornir writes hosts and groups files of Nornir SimpleInventory to output directory
+func writeNornir(hosts []inventory.Host) error {
+	if inventoryOutput == "" {
+		return fmt.Errorf("nornir inventory consists of hosts.yaml and groups.yaml, --output should name a directory for them")
+	}
+
+	err := os.MkdirAll(inventoryOutput, 0755)
+	if err != nil {
+		return err
+	}
+
+	hostsFile, err := inventory.NornirHosts(hosts)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(filepath.Join(inventoryOutput, "hosts.yaml"), hostsFile, 0644)
+	if err != nil {
+		return err
+	}
+
+	groupsFile, err := inventory.NornirGroups(hosts)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(inventoryOutput, "groups.yaml"), groupsFile, 0644)
+}
+
+// initInventoryConfig reads [inventory] section of configuration file
+func initInventoryConfig() error {
+	inventoryConfig = inventorySettings{}
+
+	err := viper.UnmarshalKey("inventory", &inventoryConfig)
+	if err != nil {
+		return fmt.Errorf("invalid 'inventory' section of configuration file: %s", err)
+	}
+
+	if inventoryConfig.ConfigPrefix == "" {
+		outputDir, err := filepath.Abs(rootDir + "/" + workspaceName + directories["output"])
+		if err != nil {
+			return err
+		}
+		inventoryConfig.ConfigPrefix = filepath.ToSlash(outputDir) + "/"
+	}
+
+	return nil
+}
+
+// inventoryHosts returns a host for every row with a value of output column. Rows appending to the same output file
+// make a single host, the first one of them is used
+func inventoryHosts(wd *workspaceData) ([]inventory.Host, error) {
+	if len(wd.raw) == 0 {
+		return nil, nil
+	}
+
+	columns := []string{inventoryConfig.HostColumn, inventoryConfig.PlatformColumn}
+	columns = append(columns, inventoryConfig.GroupColumns...)
+	columns = append(columns, inventoryConfig.VarColumns...)
+	for _, column := range columns {
+		if _, ok := wd.raw[0][column]; column != "" && !ok {
+			return nil, fmt.Errorf("invalid 'inventory' section of configuration file: no column '%s' in %s", column, csvFilename)
+		}
+	}
+	for _, column := range inventoryConfig.VarColumns {
+		if contains(inventory.ReservedVars, column) {
+			return nil, fmt.Errorf("invalid 'inventory' section of configuration file: variable '%s' is set by inventory itself, use host_column or platform_column instead", column)
+		}
+	}
+
+	varColumns := inventoryConfig.VarColumns
+	if len(varColumns) == 0 {
+		for column := range wd.raw[0] {
+			if column != templateColumnName && column != outputColumnName && !contains(inventory.ReservedVars, column) {
+				varColumns = append(varColumns, column)
+			}
+		}
+	}
+
+	var hosts []inventory.Host
+	seen := make(map[string]bool)
+
+	for i, row := range wd.raw {
+		name := strings.TrimSpace(row[outputColumnName])
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		host := inventory.Host{
+			Name:     name,
+			Platform: strings.TrimSpace(row[inventoryConfig.PlatformColumn]),
+			Vars:     make(map[string]interface{}),
+		}
+		if inventoryConfig.HostColumn != "" {
+			// addresses may be given with a prefix length, e.g. 10.0.0.1/24
+			host.Address = strings.Split(strings.TrimSpace(row[inventoryConfig.HostColumn]), "/")[0]
+		}
+
+		for _, column := range inventoryConfig.GroupColumns {
+			if group := inventory.GroupName(row[column]); group != "" {
+				host.Groups = append(host.Groups, group)
+			}
+		}
+
+		for _, column := range varColumns {
+			switch v := wd.rows[i][column].(type) {
+			case int, float64, bool, []string:
+				host.Vars[column] = v
+			default:
+				// other types (e.g. ipv4net or date) are exported as they are written in a data file
+				host.Vars[column] = row[column]
+			}
+		}
+
+		if row[templateColumnName] != "" {
+			_, outputName, err := wd.rowTemplate(i)
+			if err != nil {
+				return nil, err
+			}
+			host.ConfigFile = inventoryConfig.ConfigPrefix + outputName
+		}
+
+		hosts = append(hosts, host)
+	}
+
+	return hosts, nil
+}
+
+func init() {
+	exportInventoryCmd.Flags().StringVarP(&workspaceName, "name", "n", "", "workspace to export inventory of")
+	exportInventoryCmd.MarkFlagRequired("name")
+	exportInventoryCmd.Flags().StringVarP(&workspaceConfig, "config", "c", "workspace.toml", "configuration file to use generator for")
+	exportInventoryCmd.Flags().StringSliceVar(&profiles, "profile", nil, "profiles of configuration file to apply, in order")
+	exportInventoryCmd.Flags().StringVarP(&inventoryFormat, "format", "f", "ansible", "format of inventory: ansible, ansible-ini, nornir or napalm")
+	exportInventoryCmd.Flags().StringVarP(&inventoryOutput, "output", "o", "", "file (or directory for nornir) to write inventory to")
+
+	exportCmd.AddCommand(exportInventoryCmd)
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,72 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInventoryHostsReservedVars(t *testing.T) {
+	var testCases = []struct {
+		config   string
+		expected []string
+		isErr    bool
+	}{
+		{config: "", expected: []string{"site"}},
+		{config: "[inventory]\nvar_columns = [\"site\"]\n", expected: []string{"site"}},
+		{config: "[inventory]\nvar_columns = [\"site\", \"ansible_host\"]\n", isErr: true},
+	}
+
+	for _, tc := range testCases {
+		func() {
+			defer setupWorkspace(t, map[string]string{
+				"workspace.toml": "template_column_name = \"router\"\noutput_column_name = \"hostname\"\n" + tc.config,
+				"data/data.csv":  "hostname,router,ansible_host,config_file,site\nr1,,10.0.0.1,r1.txt,waw\n",
+			})()
+
+			setDefaults()
+			if err := initConfig(); err != nil {
+				t.Fatal(err)
+			}
+			if err := initInventoryConfig(); err != nil {
+				t.Fatal(err)
+			}
+			wd, err := loadData()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hosts, err := inventoryHosts(wd)
+			if tc.isErr {
+				if err == nil {
+					t.Errorf("expected to get an error for '%s', instead got nil", tc.config)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var vars []string
+			for name := range hosts[0].Vars {
+				vars = append(vars, name)
+			}
+			if !reflect.DeepEqual(vars, tc.expected) {
+				t.Errorf("expected to get variables %v for '%s', instead got %v", tc.expected, tc.config, vars)
+			}
+		}()
+	}
+}
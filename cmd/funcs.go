@@ -0,0 +1,51 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pegaz/go-tmpl/text"
+	"github.com/spf13/cobra"
+)
+
+// funcsCmd represents the funcs command
+var funcsCmd = &cobra.Command{
+	Use:   "funcs [filter]",
+	Short: "List functions available in templates",
+	Args:  cobra.MaximumNArgs(1),
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+		fmt.Fprintln(w, "FUNCTION\tEXAMPLE\tRESULT")
+		for _, doc := range text.FuncDocs() {
+			if len(args) > 0 && !strings.Contains(doc.Name, args[0]) {
+				continue
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\n", doc.Signature, doc.Example, strings.Replace(doc.Result, "\n", `\n`, -1))
+		}
+
+		return w.Flush()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(funcsCmd)
+}
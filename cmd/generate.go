@@ -15,12 +15,25 @@
 package cmd
 
 import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
-
+	"sync"
+	"time"
+
+	"github.com/pegaz/go-tmpl/bundle"
+	"github.com/pegaz/go-tmpl/cache"
+	"github.com/pegaz/go-tmpl/config"
+	"github.com/pegaz/go-tmpl/lint"
+	"github.com/pegaz/go-tmpl/stage"
 	"github.com/pegaz/go-tmpl/text"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -29,22 +42,71 @@ import (
 const (
 	DefaultCsvDelimiter = ','
 	DefaultCsvDataFile  = "data.csv"
+	DefaultOutputExt    = ".txt"
+
+	// cacheFilename is a file in a workspace directory with inputs of generated files
+	cacheFilename = ".go-tmpl-cache.json"
 )
 
 var (
 	workspaceName      string
 	workspaceConfig    string
+	profiles           []string
+	workspaceSettings  *config.Config
 	outputColumnName   string
 	templateColumnName string
 	csvFilename        string
 	csvDelimiter       rune
 	missingKey         string
 	overrideOutput     bool
+	timestamp          string
+	bundlePath         string
+	signKey            string
+	delims             []string
+	postProcess        []string
+	encoding           text.Encoding
+	columnTypes        map[string]string
+	aggregates         []aggregate
+	lintRules          []lintRule
 
 	fileCounter int64
 	outputFiles []string
+	// rendered holds content of generated files (before encoding) and linters to check them with
+	rendered      = make(map[string]*strings.Builder)
+	outputLinters = make(map[string]string)
+	// checkedTemplates holds names of templates already checked for suspicious delimiters, templates are set up
+	// concurrently by serve command, so it's guarded by checkedMu
+	checkedTemplates = make(map[string]bool)
+	checkedMu        sync.Mutex
+	// outputSources holds rows and templates output files are generated from
+	outputSources = make(map[string]*bundle.File)
+	// outputCache holds inputs of files generated so far, upToDate holds files which weren't generated again
+	outputCache *cache.Cache
+	upToDate    []string
+	// outputStage is a staging copy of the output directory generated files are written to
+	outputStage *stage.Stage
+	// pruneOrphans removes files which aren't generated anymore
+	pruneOrphans bool
 )
 
+// rowOutput is a row of a data file with its template
+type rowOutput struct {
+	row  int
+	tmpl *text.Template
+}
+
+// aggregate is a template executed once with all rows of a data file, e.g. to build DNS zone or inventory
+type aggregate struct {
+	Template string
+	Output   string
+}
+
+// lintRule assigns a linter to output files matching a pattern, e.g. '*.cfg' or 'junos/*'
+type lintRule struct {
+	Files  string
+	Linter string
+}
+
 // generateCmd represents the generate command
 var generateCmd = &cobra.Command{
 	Use:   "generate",
@@ -53,6 +115,8 @@ var generateCmd = &cobra.Command{
 	SilenceUsage: true,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
+		resetOutputs()
+
 		// set some default config parameters
 		setDefaults()
 
@@ -61,91 +125,114 @@ var generateCmd = &cobra.Command{
 			return err
 		}
 
-		csvReader, err := os.Open(csvFilename)
-		if err != nil {
-			return err
+		if bundlePath != "" {
+			if _, err = bundle.FormatOf(bundlePath); err != nil {
+				return err
+			}
+		} else if signKey != "" {
+			return fmt.Errorf("--sign can be used with --bundle only")
 		}
-		defer csvReader.Close()
 
-		data, err := text.ReadCSV(csvReader, csvDelimiter)
+		wd, err := loadData()
 		if err != nil {
 			return err
 		}
 
-		// Global variables (defined in configuration file within a [vars] section
-		vars := viper.Sub("vars")
-		varsName := vars.AllKeys()
-		globalVars := make(map[string]string)
-		for _, name := range varsName {
-			globalVars[name] = vars.GetString(name)
+		outputCache, err = cache.Load(cachePath())
+		if err != nil {
+			fmt.Printf("warning: %s\n", err)
 		}
 
-		if overrideOutput == true {
-			// Delete content of the output's directory
-			err = pruneDirContent(rootDir + "/" + workspaceName + directories["output"])
-			if err != nil {
-				return err
-			}
+		// files are written to a staging copy of the output directory, which replaces it when all of them are
+		// generated. With --force files generated before are dropped, so all of them are generated again
+		previous := outputCache
+		if overrideOutput {
+			outputCache = cache.New(cachePath())
 		}
+		outputStage, err = stage.New(rootDir+"/"+workspaceName+directories["output"], func(name string) bool {
+			_, generated := previous.Outputs[name]
+			return !overrideOutput || !generated
+		})
+		if err != nil {
+			return err
+		}
+		defer outputStage.Abort()
+
+		// paths of output files of rows are used by DHCP reservations
+		rowOutputs := make([]string, len(wd.raw))
 
-		for _, d := range data {
-			var outputFile io.WriteCloser
+		// rows appending to the same output file are generated together
+		var outputNames []string
+		outputRows := make(map[string][]rowOutput)
 
-			templateFilename, ok := d[templateColumnName]
-			if !ok {
+		for i, d := range wd.raw {
+			if _, ok := d[templateColumnName]; !ok {
 				fmt.Printf("couldn't find '%s' column in data provided", templateColumnName)
 				continue
 			}
-			outputFilename, ok := d[outputColumnName]
-			if !ok {
+			if _, ok := d[outputColumnName]; !ok {
 				fmt.Printf("couldn't find '%s' column in data provided", outputColumnName)
 				continue
 			}
 
-			templatePath := rootDir + "/" + workspaceName + directories["templates"] + "/" + templateFilename
-			if templatePath[len(templatePath)-4:] != ".tpl" {
-				templatePath = templatePath + ".tpl"
-			}
-			templateReader, err := os.Open(templatePath)
+			tmpl, outputName, err := wd.rowTemplate(i)
 			if err != nil {
 				return err
 			}
-			defer templateReader.Close()
+			rowOutputs[i] = outputName
+			addSource(outputName, i+1, tmpl)
 
-			var tmpl *text.Template
+			if _, ok := outputRows[outputName]; !ok {
+				outputNames = append(outputNames, outputName)
+			}
+			outputRows[outputName] = append(outputRows[outputName], rowOutput{i, tmpl})
+		}
 
-			tmpl, err = text.NewTemplate(d, templateFilename, templateReader)
+		for _, outputName := range outputNames {
+			err = generateRows(wd, outputName, outputRows[outputName])
 			if err != nil {
 				return err
 			}
+		}
 
-			// Global variables defined in configuration file for a workspace goes to Template
-			tmpl.SetGlobalVars(globalVars)
-			tmpl.SetStrict(missingKey)
-
-			var flags int
-			if contains(outputFiles, outputFilename) {
-				flags = os.O_APPEND
-			} else {
-				// Check if given file already exist and if so don't generate output for it
-				_, err = os.Stat(rootDir + "/" + workspaceName + directories["output"] + "/" + outputFilename + ".txt")
-				if err == nil {
-					continue
-				}
-
-				flags = os.O_CREATE
-				outputFiles = append(outputFiles, outputFilename)
+		for _, agg := range aggregates {
+			err = generateAggregate(agg, wd)
+			if err != nil {
+				return err
 			}
+		}
 
-			outputFile, err = os.OpenFile(rootDir+"/"+workspaceName+directories["output"]+"/"+outputFilename+".txt", flags, 0644)
+		if dhcpConfig.Kea != "" || dhcpConfig.ISC != "" {
+			err = generateDHCP(wd, rowOutputs)
 			if err != nil {
 				return err
 			}
-			defer outputFile.Close()
+		}
+
+		err = removeOrphans()
+		if err != nil {
+			return err
+		}
+
+		// output directory isn't replaced when any of generated files has syntax problems
+		err = lintOutputs()
+		if err != nil {
+			return err
+		}
 
-			err = tmpl.Execute(outputFile)
+		err = outputStage.Commit()
+		if err != nil {
+			return fmt.Errorf("can't replace output directory: %s", err)
+		}
+
+		err = outputCache.Save()
+		if err != nil {
+			return err
+		}
+
+		if bundlePath != "" {
+			err = writeBundle(wd)
 			if err != nil {
-				fmt.Printf("error generating file from template: %s", err)
 				return err
 			}
 		}
@@ -156,6 +243,11 @@ var generateCmd = &cobra.Command{
 			}
 			fmt.Println()
 			fmt.Printf("Succesfully generated %d output files", len(outputFiles))
+			if len(upToDate) > 0 {
+				fmt.Printf(", %d files are up to date", len(upToDate))
+			}
+		} else if len(upToDate) > 0 {
+			fmt.Printf("Nothing to do, %d files are up to date", len(upToDate))
 		} else {
 			fmt.Print("Nothing to do")
 		}
@@ -164,6 +256,600 @@ var generateCmd = &cobra.Command{
 	},
 }
 
+// resetOutputs forgets files generated by a previous run, so generation may be run again within the same process
+func resetOutputs() {
+	outputFiles = nil
+	upToDate = nil
+	rendered = make(map[string]*strings.Builder)
+	outputLinters = make(map[string]string)
+	outputSources = make(map[string]*bundle.File)
+}
+
+// generationMeta returns information about the generation available to templates as .Meta. Time of the generation
+// may be fixed with --timestamp flag, so generated files are reproducible
+func generationMeta() (text.Meta, error) {
+	meta := text.Meta{
+		Time:      time.Now(),
+		Version:   version,
+		Workspace: workspaceName,
+		DataFile:  viper.GetString("csv_data"),
+	}
+
+	if timestamp != "" {
+		t, err := text.ParseTimestamp(timestamp)
+		if err != nil {
+			return meta, err
+		}
+		meta.Time = t
+	}
+
+	b, err := ioutil.ReadFile(csvFilename)
+	if err != nil {
+		return meta, err
+	}
+	sum := sha256.Sum256(b)
+	meta.DataHash = hex.EncodeToString(sum[:])
+
+	// commit is left empty when git isn't installed or workspace isn't a git repository
+	out, err := exec.Command("git", "-C", rootDir+"/"+workspaceName, "rev-parse", "HEAD").Output()
+	if err == nil {
+		meta.Commit = strings.TrimSpace(string(out))
+	}
+
+	return meta, nil
+}
+
+// typesOfColumns returns types set in configuration file for columns of a data file. Column names are matched
+// case-insensitively, as keys of configuration file are lower cased
+func typesOfColumns(data []map[string]string) map[string]string {
+	types := make(map[string]string)
+	if len(data) == 0 {
+		return types
+	}
+
+	for column := range data[0] {
+		if typ, ok := columnTypes[strings.ToLower(column)]; ok {
+			types[column] = typ
+		}
+	}
+
+	return types
+}
+
+// templatePath returns path of a template file with a given name
+func templatePath(name string) string {
+	path := rootDir + "/" + workspaceName + directories["templates"] + "/" + name
+	if !strings.HasSuffix(path, text.TemplateExt) {
+		path = path + text.TemplateExt
+	}
+
+	return path
+}
+
+// workspaceData holds data of a workspace shared by all templates
+type workspaceData struct {
+	// raw holds rows as they are read from data file, rows holds them with values converted to types of columns
+	raw     []map[string]string
+	rows    []map[string]interface{}
+	dataset *text.Dataset
+	vars    map[string]string
+	meta    text.Meta
+}
+
+// loadData reads data file of a workspace and global variables from its configuration file
+func loadData() (*workspaceData, error) {
+	csvReader, err := os.Open(csvFilename)
+	if err != nil {
+		return nil, err
+	}
+	defer csvReader.Close()
+
+	data, err := text.ReadCSV(csvReader, csvDelimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > 0 {
+		if _, ok := data[0][text.MetaKey]; ok {
+			return nil, fmt.Errorf("column '%s' in %s is reserved for information about the generation", text.MetaKey, csvFilename)
+		}
+	}
+
+	wd := &workspaceData{raw: data}
+
+	wd.meta, err = generationMeta()
+	if err != nil {
+		return nil, err
+	}
+
+	// Values of columns with a type set in configuration file are converted to it
+	wd.rows, err = text.Coerce(data, typesOfColumns(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid data in %s: %s", csvFilename, err)
+	}
+
+	// All rows are available to templates through lookup functions
+	wd.dataset = text.NewDataset(wd.rows)
+
+	// Global variables (defined in configuration file within a [vars] section
+	vars := viper.Sub("vars")
+	wd.vars = make(map[string]string)
+	if vars != nil {
+		for _, name := range vars.AllKeys() {
+			wd.vars[name] = vars.GetString(name)
+		}
+	}
+
+	return wd, nil
+}
+
+// rowTemplate returns a template of i-th row configured with settings of a workspace together with a path of its
+// output file (relative to output directory)
+func (wd *workspaceData) rowTemplate(i int) (*text.Template, string, error) {
+	templateFilename := wd.raw[i][templateColumnName]
+
+	templateReader, err := os.Open(templatePath(templateFilename))
+	if err != nil {
+		return nil, "", err
+	}
+	defer templateReader.Close()
+
+	// row is copied, as global variables are added to it
+	row := make(map[string]interface{}, len(wd.rows[i]))
+	for k, v := range wd.rows[i] {
+		row[k] = v
+	}
+
+	tmpl, err := text.NewTypedTemplate(row, templateFilename, templateReader)
+	if err != nil {
+		return nil, "", err
+	}
+	wd.setup(tmpl)
+
+	outputName, err := outputPath(tmpl, wd.raw[i][outputColumnName])
+	if err != nil {
+		return nil, "", err
+	}
+
+	return tmpl, outputName, nil
+}
+
+// setup applies workspace configuration to a template and prints its warnings (once per template)
+func (wd *workspaceData) setup(tmpl *text.Template) {
+	// Global variables defined in configuration file for a workspace goes to Template
+	tmpl.SetGlobalVars(wd.vars)
+	tmpl.SetStrict(missingKey)
+	tmpl.SetDelims(delims[0], delims[1])
+	tmpl.SetPostProcess(postProcess)
+	tmpl.SetEncoding(encoding)
+	tmpl.SetDataset(wd.dataset)
+	tmpl.SetMeta(wd.meta)
+	// Base layouts are looked up in the same directory as templates
+	tmpl.SetLoader(text.DirLoader(rootDir + "/" + workspaceName + directories["templates"]))
+
+	checkedMu.Lock()
+	defer checkedMu.Unlock()
+
+	if !checkedTemplates[tmpl.TemplateName] {
+		for _, warning := range tmpl.Warnings() {
+			fmt.Printf("warning: template %s: %s\n", tmpl.TemplateName, warning)
+		}
+		checkedTemplates[tmpl.TemplateName] = true
+	}
+}
+
+// generateAggregate executes an aggregate template once with all rows of a data file and writes its output
+// to a file named in configuration file
+func generateAggregate(agg aggregate, wd *workspaceData) error {
+	templateReader, err := os.Open(templatePath(agg.Template))
+	if err != nil {
+		return err
+	}
+	defer templateReader.Close()
+
+	tmpl, err := text.NewTypedTemplate(map[string]interface{}{}, agg.Template, templateReader)
+	if err != nil {
+		return err
+	}
+
+	wd.setup(tmpl)
+	tmpl.SetRows(wd.dataset.Rows())
+
+	outputName, err := checkOutputName(agg.Output)
+	if err != nil {
+		return fmt.Errorf("invalid output of aggregate template %s: %s", agg.Template, err)
+	}
+	if source, ok := outputSources[outputName]; ok && len(source.Rows) > 0 {
+		return fmt.Errorf("output %s of aggregate template %s is already generated from data file", outputName, agg.Template)
+	}
+	addSource(outputName, 0, tmpl)
+
+	tmplKey, err := templateKey(tmpl)
+	if err != nil {
+		return err
+	}
+	key := wd.key("aggregate", tmplKey)
+	if wd.fresh(outputName, key) {
+		return nil
+	}
+
+	var output strings.Builder
+	err = tmpl.Execute(&output)
+	if err != nil {
+		fmt.Printf("error generating file from template: %s", err)
+		return err
+	}
+
+	encoded, err := tmpl.Encoding().Encode(output.String())
+	if err != nil {
+		return fmt.Errorf("can't write %s: %s", outputName, err)
+	}
+
+	addRendered(outputName, output.String(), tmpl.FrontMatter.Lint)
+
+	var metaKey string
+	if tmpl.UsedMeta() {
+		metaKey = wd.metaKey()
+	}
+
+	// aggregate templates depend on all rows
+	return writeOutput(outputName, encoded, key, wd.meta.DataHash, metaKey)
+}
+
+// checkOutputName cleans a path of an output file named in configuration file and checks if it points inside
+// of the output directory
+func checkOutputName(name string) (string, error) {
+	outputName := filepath.Clean(name)
+	if filepath.IsAbs(outputName) || strings.HasPrefix(outputName, "..") {
+		return "", fmt.Errorf("output path %s points outside of the output directory", outputName)
+	}
+
+	return filepath.ToSlash(outputName), nil
+}
+
+// generateRows generates an output file from all rows appending to it, unless the file is up to date
+func generateRows(wd *workspaceData, outputName string, rows []rowOutput) error {
+	inputs := []string{"rows"}
+	for _, r := range rows {
+		tmplKey, err := templateKey(r.tmpl)
+		if err != nil {
+			return err
+		}
+		inputs = append(inputs, rowKey(wd.raw[r.row]), tmplKey)
+	}
+	key := wd.key(inputs...)
+	if wd.fresh(outputName, key) {
+		return nil
+	}
+
+	var encoded []byte
+	var dataHash, metaKey string
+
+	for _, r := range rows {
+		var output strings.Builder
+		err := r.tmpl.Execute(&output)
+		if err != nil {
+			fmt.Printf("error generating file from template: %s", err)
+			return err
+		}
+
+		e, err := r.tmpl.Encoding().Encode(output.String())
+		if err != nil {
+			return fmt.Errorf("can't write %s: %s", outputName, err)
+		}
+		encoded = append(encoded, e...)
+		addRendered(outputName, output.String(), r.tmpl.FrontMatter.Lint)
+
+		// templates with lookups depend on other rows as well
+		if r.tmpl.UsedDataset() {
+			dataHash = wd.meta.DataHash
+		}
+		// and templates referring to .Meta depend on the generation
+		if r.tmpl.UsedMeta() {
+			metaKey = wd.metaKey()
+		}
+	}
+
+	return writeOutput(outputName, encoded, key, dataHash, metaKey)
+}
+
+// writeOutput writes a generated file to the staging directory and records a key of its inputs in the cache. Files
+// depending on all rows of a data file are recorded with its checksum, so they are generated again when any row
+// is changed. Likewise files referring to .Meta are recorded with metaKey
+func writeOutput(outputName string, encoded []byte, key string, dataHash string, metaKey string) error {
+	err := outputStage.WriteFile(outputName, encoded)
+	if err != nil {
+		return err
+	}
+	outputFiles = append(outputFiles, outputName)
+
+	outputCache.Put(outputName, key, dataHash, metaKey, cache.Sum(encoded))
+
+	return nil
+}
+
+// cachePath returns path of a file with inputs of generated files, it is kept in a workspace directory
+func cachePath() string {
+	return rootDir + "/" + workspaceName + "/" + cacheFilename
+}
+
+// key returns a checksum of given inputs together with settings of a workspace and version of go-tmpl, so all files
+// are generated again when any of them is changed
+func (wd *workspaceData) key(inputs ...string) string {
+	settings := []string{version, missingKey, strings.Join(delims, " "), strings.Join(postProcess, " "), fmt.Sprintf("%+v", encoding)}
+	settings = append(settings, sortedPairs(wd.vars)...)
+	settings = append(settings, sortedPairs(columnTypes)...)
+
+	return cache.Key(append(settings, inputs...)...)
+}
+
+// fresh reports whether an output file is generated from inputs with a given key and wasn't changed since then.
+// Fresh files are remembered as up to date
+func (wd *workspaceData) fresh(outputName string, key string) bool {
+	var sum string
+	if b, err := ioutil.ReadFile(rootDir + "/" + workspaceName + directories["output"] + "/" + outputName); err == nil {
+		sum = cache.Sum(b)
+	}
+
+	if !outputCache.Fresh(outputName, key, wd.meta.DataHash, wd.metaKey(), sum) {
+		return false
+	}
+	upToDate = append(upToDate, outputName)
+
+	return true
+}
+
+// metaKey returns a checksum of information about the generation available to templates as .Meta
+func (wd *workspaceData) metaKey() string {
+	m := wd.meta
+
+	return cache.Key(m.Time.Format(time.RFC3339Nano), m.Version, m.Workspace, m.DataFile, m.DataHash, m.Commit)
+}
+
+// rowKey returns a checksum of values of a row as they are written in a data file
+func rowKey(row map[string]string) string {
+	return cache.Key(sortedPairs(row)...)
+}
+
+// templateKey returns a checksum of a template file and all base templates it extends
+func templateKey(tmpl *text.Template) (string, error) {
+	bases, err := tmpl.Bases()
+	if err != nil {
+		return "", err
+	}
+
+	var contents []string
+	for _, name := range append(bases, tmpl.TemplateName) {
+		b, err := ioutil.ReadFile(templatePath(name))
+		if err != nil {
+			return "", err
+		}
+		contents = append(contents, name, string(b))
+	}
+
+	return cache.Key(contents...), nil
+}
+
+// sortedPairs returns keys and values of a map sorted by keys
+func sortedPairs(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, 2*len(m))
+	for _, k := range keys {
+		pairs = append(pairs, k, m[k])
+	}
+
+	return pairs
+}
+
+// removeOrphans reports files generated before which aren't generated anymore (e.g. of removed or renamed devices)
+// and removes them with --prune. Files which were never generated (e.g. README.md) aren't touched
+func removeOrphans() error {
+	var orphans []string
+	for name := range outputCache.Outputs {
+		if !contains(outputFiles, name) && !contains(upToDate, name) {
+			orphans = append(orphans, name)
+		}
+	}
+	sort.Strings(orphans)
+
+	for _, name := range orphans {
+		if !pruneOrphans {
+			if _, err := os.Stat(filepath.Join(outputStage.Dir, name)); err != nil {
+				// file is already removed
+				outputCache.Delete(name)
+				continue
+			}
+			fmt.Printf("orphan: %s isn't generated anymore, remove it with --prune\n", name)
+			continue
+		}
+
+		removed, err := outputStage.Remove(name)
+		if err != nil {
+			return err
+		}
+		if removed {
+			fmt.Printf("orphan: %s removed\n", name)
+		}
+		outputCache.Delete(name)
+	}
+
+	return nil
+}
+
+// addRendered remembers content generated for an output file (files may be generated from several rows)
+// and a linter set for it in template's front-matter
+func addRendered(outputName string, content string, linter string) {
+	if _, ok := rendered[outputName]; !ok {
+		rendered[outputName] = &strings.Builder{}
+		outputLinters[outputName] = linter
+	}
+	rendered[outputName].WriteString(content)
+}
+
+// addSource remembers a row (0 for files generated once for the whole data file) and a template an output file is
+// generated from. Checksum of a template covers its base templates too (contents of the most basic one first),
+// it is computed once
+func addSource(outputName string, row int, tmpl *text.Template) {
+	source, ok := outputSources[outputName]
+	if !ok {
+		source = &bundle.File{Path: outputName, Template: tmpl.TemplateName}
+		if bases, err := tmpl.Bases(); err == nil {
+			var chain []byte
+			for _, name := range append(bases, tmpl.TemplateName) {
+				b, err := ioutil.ReadFile(templatePath(name))
+				if err != nil {
+					chain = nil
+					break
+				}
+				chain = append(chain, b...)
+			}
+			if chain != nil {
+				source.Bases = bases
+				source.TemplateSHA256 = bundle.Sum(chain)
+			}
+		}
+		outputSources[outputName] = source
+	}
+
+	if row > 0 {
+		source.Rows = append(source.Rows, row)
+	}
+}
+
+// writeBundle packages generated and up-to-date files into a bundle with a manifest of their sources, signed
+// with a given key
+func writeBundle(wd *workspaceData) error {
+	var key ed25519.PrivateKey
+	if signKey != "" {
+		var err error
+		key, err = bundle.ReadPrivateKey(signKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	manifest := bundle.Manifest{
+		Version:    version,
+		Workspace:  workspaceName,
+		Generated:  wd.meta.Time,
+		Commit:     wd.meta.Commit,
+		DataFile:   wd.meta.DataFile,
+		DataSHA256: wd.meta.DataHash,
+	}
+	contents := make(map[string][]byte)
+
+	for _, outputName := range append(append([]string{}, outputFiles...), upToDate...) {
+		content, err := ioutil.ReadFile(rootDir + "/" + workspaceName + directories["output"] + "/" + outputName)
+		if err != nil {
+			return err
+		}
+		contents[outputName] = content
+
+		file := bundle.File{Path: outputName}
+		if source, ok := outputSources[outputName]; ok {
+			file = *source
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	err := bundle.WriteFile(bundlePath, manifest, contents, key)
+	if err != nil {
+		return fmt.Errorf("can't write bundle %s: %s", bundlePath, err)
+	}
+	fmt.Printf("bundle %s: %d files\n", bundlePath, len(contents))
+
+	return nil
+}
+
+// linterOf returns name of a linter for an output file: the one set in template's front-matter or in the first
+// 'lint' rule of configuration file with a pattern matching path or name of a file. Empty name means no linting
+func linterOf(outputName string) string {
+	if name := outputLinters[outputName]; name != "" {
+		if name == "none" {
+			return ""
+		}
+		return name
+	}
+
+	for _, rule := range lintRules {
+		if ok, _ := path.Match(rule.Files, outputName); ok {
+			return rule.Linter
+		}
+		if ok, _ := path.Match(rule.Files, path.Base(outputName)); ok {
+			return rule.Linter
+		}
+	}
+
+	return ""
+}
+
+// lintOutputs checks syntax of all generated files and prints problems found with their line numbers
+func lintOutputs() error {
+	var failed int
+
+	for _, outputName := range outputFiles {
+		name := linterOf(outputName)
+		if name == "" {
+			continue
+		}
+
+		problems, err := lint.Lint(name, rendered[outputName].String())
+		if err != nil {
+			return fmt.Errorf("can't lint %s: %s", outputName, err)
+		}
+
+		for _, problem := range problems {
+			if problem.Line == 0 {
+				fmt.Printf("lint: %s: %s\n", outputName, problem.Message)
+			} else {
+				fmt.Printf("lint: %s:%d: %s\n", outputName, problem.Line, problem.Message)
+			}
+		}
+		if len(problems) > 0 {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("syntax problems found in %d generated files", failed)
+	}
+
+	return nil
+}
+
+// outputPath returns path of an output file (relative to output directory) for a given template. By default it is
+// a value of output column with '.txt' extension, both can be changed in template's front-matter
+func outputPath(tmpl *text.Template, outputFilename string) (string, error) {
+	ext := DefaultOutputExt
+	if tmpl.FrontMatter.Extension != "" {
+		ext = "." + strings.TrimPrefix(tmpl.FrontMatter.Extension, ".")
+	}
+
+	if tmpl.FrontMatter.Path != "" {
+		path, err := tmpl.ExpandPath()
+		if err != nil {
+			return "", fmt.Errorf("invalid output path pattern in template %s: %s", tmpl.TemplateName, err)
+		}
+		outputFilename = path
+	}
+
+	if name := strings.TrimSpace(outputFilename); name == "" || strings.HasSuffix(name, "/") {
+		return "", fmt.Errorf("output path '%s' of template %s has no file name", outputFilename, tmpl.TemplateName)
+	}
+
+	outputName := filepath.Clean(outputFilename + ext)
+	if filepath.IsAbs(outputName) || strings.HasPrefix(outputName, "..") {
+		return "", fmt.Errorf("output path %s of template %s points outside of the output directory", outputName, tmpl.TemplateName)
+	}
+
+	return filepath.ToSlash(outputName), nil
+}
+
 func contains(arr []string, str string) bool {
 	for _, a := range arr {
 		if a == str {
@@ -175,33 +861,40 @@ func contains(arr []string, str string) bool {
 
 func init() {
 	generateCmd.Flags().BoolVarP(&overrideOutput, "force", "f", false, "override the content of output directory")
+	generateCmd.Flags().BoolVar(&pruneOrphans, "prune", false, "remove files which aren't generated anymore")
 	generateCmd.Flags().StringVarP(&workspaceName, "name", "n", "", "workspace to generate files for")
 	generateCmd.MarkFlagRequired("workspace")
 
 	generateCmd.Flags().StringVarP(&workspaceConfig, "config", "c", "workspace.toml", "configuration file to use generator for")
+	generateCmd.Flags().StringSliceVar(&profiles, "profile", nil, "profiles of configuration file to apply, in order")
+	generateCmd.Flags().StringVar(&timestamp, "timestamp", "", "time of the generation (RFC 3339 or unix time) used instead of the current one")
+	generateCmd.Flags().StringVar(&bundlePath, "bundle", "", "package generated files with a manifest into a bundle (.tar.gz, .tgz or .zip)")
+	generateCmd.Flags().StringVar(&signKey, "sign", "", "ed25519 private key (OpenSSH format) to sign manifest of a bundle with")
 
 	rootCmd.AddCommand(generateCmd)
 }
 
 func setDefaults() {
 	viper.SetDefault("csv_data", DefaultCsvDataFile)
-	viper.SetDefault("csv_delimiter", DefaultCsvDelimiter)
+	viper.SetDefault("csv_delimiter", string(DefaultCsvDelimiter))
 	viper.SetDefault("missingkey", "invalid")
 	viper.SetDefault("override_output", "false")
+	viper.SetDefault("delims", []string{text.DefaultLeftDelim, text.DefaultRightDelim})
+	viper.SetDefault("serve.http", DefaultHTTPAddr)
+	viper.SetDefault("serve.tftp", DefaultTFTPAddr)
 }
 
+// initConfig reads configuration file of a workspace together with base files it extends, profiles given
+// with --profile and global variables overridden with environment variables
 func initConfig() error {
 	var err error
-	var file *os.File
 
-	viper.SetConfigType("toml")
-
-	file, err = os.Open(rootDir + "/" + workspaceName + "/" + workspaceConfig)
+	workspaceSettings, err = config.Load(rootDir+"/"+workspaceName+"/"+workspaceConfig, profiles, os.Environ())
 	if err != nil {
 		return err
 	}
 
-	err = viper.ReadConfig(file)
+	err = viper.MergeConfigMap(workspaceSettings.Settings)
 	if err != nil {
 		return err
 	}
@@ -220,25 +913,66 @@ func initConfig() error {
 	} else if viper.IsSet("missing_key") {
 		return fmt.Errorf("invalid value for 'missing_key' value in configuration file, got: %s", viper.GetString("missing_key"))
 	}
-	csvFilename = rootDir + "/" + workspaceName + directories["data"] + "/" + viper.GetString("csv_data")
+	delims = viper.GetStringSlice("delims")
+	if len(delims) != 2 || delims[0] == "" || delims[1] == "" {
+		return fmt.Errorf("'delims' in configuration file should consist of exactly two non empty values, got: %v", delims)
+	}
 
-	return err
-}
+	postProcess = viper.GetStringSlice("postprocess")
+	err = text.CheckPostProcess(postProcess)
+	if err != nil {
+		return fmt.Errorf("invalid value for 'postprocess' in configuration file: %s", err)
+	}
 
-func pruneDirContent(dir string) error {
-	matches, err := filepath.Glob(dir + "/*")
+	encoding = text.Encoding{
+		LineEndings:  viper.GetString("line_endings"),
+		FinalNewline: viper.GetBool("final_newline"),
+		Charset:      viper.GetString("charset"),
+	}
+	err = text.CheckEncoding(encoding)
+	if err != nil {
+		return fmt.Errorf("invalid encoding of the output in configuration file: %s", err)
+	}
+
+	// keys are lower cased by viper, they are matched with columns of a data file later on
+	columnTypes = viper.GetStringMapString("types")
+	err = text.CheckColumnTypes(columnTypes)
+	if err != nil {
+		return fmt.Errorf("invalid value in 'types' section of configuration file: %s", err)
+	}
+
+	// entries of a configuration read before are dropped, as UnmarshalKey leaves them when there are no new ones
+	aggregates, lintRules = nil, nil
+	err = viper.UnmarshalKey("aggregate", &aggregates)
+	if err != nil {
+		return fmt.Errorf("invalid 'aggregate' entries in configuration file: %s", err)
+	}
+	for _, agg := range aggregates {
+		if agg.Template == "" || agg.Output == "" {
+			return fmt.Errorf("every 'aggregate' entry in configuration file should have 'template' and 'output' set")
+		}
+	}
+
+	err = initDHCPConfig()
 	if err != nil {
 		return err
 	}
 
-	for _, filename := range matches {
-		if strings.HasSuffix(filename, ".txt") || strings.HasSuffix(filename, ".cfg") {
-			err = os.Remove(filename)
-			if err != nil {
-				return err
-			}
+	err = viper.UnmarshalKey("lint", &lintRules)
+	if err != nil {
+		return fmt.Errorf("invalid 'lint' entries in configuration file: %s", err)
+	}
+	for _, rule := range lintRules {
+		if _, err = path.Match(rule.Files, ""); err != nil || rule.Files == "" {
+			return fmt.Errorf("invalid pattern '%s' of 'lint' entry in configuration file", rule.Files)
+		}
+		err = lint.Check(rule.Linter)
+		if err != nil {
+			return fmt.Errorf("invalid 'lint' entry in configuration file: %s", err)
 		}
 	}
 
-	return nil
+	csvFilename = rootDir + "/" + workspaceName + directories["data"] + "/" + viper.GetString("csv_data")
+
+	return err
 }
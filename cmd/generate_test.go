@@ -0,0 +1,184 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pegaz/go-tmpl/bundle"
+	"github.com/pegaz/go-tmpl/text"
+	"github.com/spf13/viper"
+)
+
+// setupWorkspace creates a workspace named 'lab' with given files (paths relative to the workspace directory)
+// in a temporary root directory
+func setupWorkspace(t *testing.T, files map[string]string) func() {
+	dir, err := ioutil.TempDir("", "workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rootDir = dir
+	workspaceName = "lab"
+	workspaceConfig = "workspace.toml"
+	profiles = nil
+	viper.Reset()
+
+	writeFiles(t, files)
+
+	return func() { os.RemoveAll(dir) }
+}
+
+// writeFiles writes files to a workspace created with setupWorkspace
+func writeFiles(t *testing.T, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(rootDir, workspaceName, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDefaultCsvDelimiter(t *testing.T) {
+	defer setupWorkspace(t, map[string]string{
+		"workspace.toml": "template_column_name = \"router\"\noutput_column_name = \"hostname\"\n",
+	})()
+
+	setDefaults()
+	if err := initConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	if csvDelimiter != ',' {
+		t.Errorf("expected to get ',' as default CSV delimiter, instead got '%c'", csvDelimiter)
+	}
+}
+
+func TestOutputPath(t *testing.T) {
+	var testCases = []struct {
+		content  string
+		name     string
+		expected string
+		isErr    bool
+	}{
+		{content: "config\n", name: "r1", expected: "r1" + DefaultOutputExt},
+		{content: "{{/*---\npath: \"{{.site}}/{{.hostname}}\"\nextension: cfg\n---*/}}\n", name: "r1", expected: "waw/r1.cfg"},
+		{content: "{{/*---\npath: \"{{.region}}/{{.hostname}}\"\n---*/}}\n", name: "r1", isErr: true},
+		{content: "{{/*---\npath: \"{{.site}}/\"\n---*/}}\n", name: "r1", isErr: true},
+		{content: "{{/*---\npath: \"{{.empty}}\"\n---*/}}\n", name: "r1", isErr: true},
+		{content: "config\n", name: " ", isErr: true},
+		{content: "{{/*---\npath: \"../{{.hostname}}\"\n---*/}}\n", name: "r1", isErr: true},
+	}
+
+	for _, tc := range testCases {
+		tmpl, err := text.NewTemplate(map[string]string{"site": "waw", "hostname": "r1", "empty": ""}, "test_template", strings.NewReader(tc.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		name, err := outputPath(tmpl, tc.name)
+		if tc.isErr {
+			if err == nil {
+				t.Errorf("expected to get an error for output path of '%s', instead got '%s'", tc.content, name)
+			}
+			continue
+		}
+		if err != nil || name != tc.expected {
+			t.Errorf("expected to get output path '%s', instead got '%s' (error: %v)", tc.expected, name, err)
+		}
+	}
+}
+
+func TestAddSourceChecksum(t *testing.T) {
+	base := "{{block \"body\" .}}{{end}}\n"
+	child := "{{/* extends \"base\" */}}\n{{define \"body\"}}hostname {{.hostname}}{{end}}\n"
+	defer setupWorkspace(t, map[string]string{
+		"templates/base.tpl":  base,
+		"templates/child.tpl": child,
+	})()
+	defer delete(outputSources, "r1.txt")
+
+	tmpl, err := text.NewTemplate(map[string]string{"hostname": "r1"}, "child", strings.NewReader(child))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SetLoader(text.DirLoader(filepath.Join(rootDir, workspaceName, "templates")))
+
+	addSource("r1.txt", 1, tmpl)
+
+	source := outputSources["r1.txt"]
+	if source.TemplateSHA256 != bundle.Sum([]byte(base+child)) {
+		t.Errorf("expected to get checksum of base and child templates, instead got %s", source.TemplateSHA256)
+	}
+	if len(source.Bases) != 1 || source.Bases[0] != "base" {
+		t.Errorf("expected to get 'base' as a base template, instead got %v", source.Bases)
+	}
+}
+
+func TestGenerateIncremental(t *testing.T) {
+	defer setupWorkspace(t, map[string]string{
+		"workspace.toml":      "template_column_name = \"router\"\noutput_column_name = \"hostname\"\n[vars]\ndomain = \"lab.local\"\n",
+		"data/data.csv":       "hostname,router,site\nr1,ios,waw\nr2,ios,krk\nr3,plain,waw\nr4,motd,waw\n",
+		"templates/base.tpl":  "{{block \"body\" .}}{{end}}\n",
+		"templates/ios.tpl":   "{{/* extends \"base\" */}}\n{{define \"body\"}}hostname {{.hostname}}{{end}}\n",
+		"templates/plain.tpl": "hostname {{.hostname}}.{{.domain}}\n",
+		"templates/motd.tpl":  "generated at {{.Meta.Time}}\n",
+		"output/.keep":        "",
+	})()
+	defer func() { timestamp = "" }()
+
+	testCases := []struct {
+		name      string
+		files     map[string]string
+		timestamp string
+		expected  []string
+	}{
+		{"first run", nil, "2020-01-01T00:00:00Z", []string{"r1.txt", "r2.txt", "r3.txt", "r4.txt"}},
+		{"nothing changed", nil, "2020-01-01T00:00:00Z", nil},
+		// data hash is available as .Meta, so files of templates referring to it are generated again as well
+		{"row changed", map[string]string{
+			"data/data.csv": "hostname,router,site\nr1,ios,waw\nr2,ios,gdn\nr3,plain,waw\nr4,motd,waw\n",
+		}, "2020-01-01T00:00:00Z", []string{"r2.txt", "r4.txt"}},
+		{"base layout changed", map[string]string{
+			"templates/base.tpl": "! managed\n{{block \"body\" .}}{{end}}\n",
+		}, "2020-01-01T00:00:00Z", []string{"r1.txt", "r2.txt"}},
+		{"var changed", map[string]string{
+			"workspace.toml": "template_column_name = \"router\"\noutput_column_name = \"hostname\"\n[vars]\ndomain = \"lab.example\"\n",
+		}, "2020-01-01T00:00:00Z", []string{"r1.txt", "r2.txt", "r3.txt", "r4.txt"}},
+		{"timestamp changed", nil, "2020-01-02T00:00:00Z", []string{"r4.txt"}},
+		{"timestamp not fixed", nil, "", []string{"r4.txt"}},
+	}
+
+	for _, tc := range testCases {
+		writeFiles(t, tc.files)
+		viper.Reset()
+		timestamp = tc.timestamp
+
+		if err := generateCmd.RunE(generateCmd, nil); err != nil {
+			t.Fatalf("%s: %s", tc.name, err)
+		}
+
+		if strings.Join(outputFiles, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("%s: expected to get %v generated, instead got %v", tc.name, tc.expected, outputFiles)
+		}
+	}
+}
@@ -27,6 +27,8 @@ var directories = map[string]string{
 	"templates": "/templates",
 	"data":      "/data",
 	"output":    "/output",
+	"running":   "/running",
+	"logs":      "/logs",
 }
 
 // initCmd represents the init command
@@ -58,7 +60,9 @@ func createWorkspace(name string) error {
 	var err error
 
 	files := map[string][]byte{
-		rootDir + "/" + name + "/workspace.toml": []byte(`# CSV data filename, it should be placed in data directory inside of a given workspace
+		rootDir + "/" + name + "/workspace.toml": []byte(`# base configuration file (relative to this one) which settings of this file are merged into
+#extends = "base.toml"
+# CSV data filename, it should be placed in data directory inside of a given workspace
 #csv_data = "data.csv"
 # delimiter used in CSV file as a field separator
 #csv_delimiter = ","
@@ -69,12 +73,93 @@ func createWorkspace(name string) error {
 #missing_key = "invalid"
 # by default output folder content won't be overriden
 #override_output = false
+# delimiters of template's actions, may be changed when generated output contains '{{' and '}}'
+#delims = ["{{", "}}"]
+# post-processing steps applied to every generated output
+# trim_trailing - trailing whitespaces are stripped from every line
+# collapse_blank - consecutive blank lines are collapsed into a single one
+# indent_includes - lines of blocks rendered with 'include' are indented to the include site
+#postprocess = ["trim_trailing", "collapse_blank", "indent_includes"]
+# line endings of generated files: lf, crlf or keep (as they are in templates)
+#line_endings = "keep"
+# new line is appended to generated files which don't end with one
+#final_newline = false
+# charset of generated files, e.g. utf-8, iso-8859-2, windows-1250
+#charset = "utf-8"
 
 template_column_name = "router"
 output_column_name = "hostname"
 
 [vars]
 # custom vars to use them inside of templates should be placed here
+
+[types]
+# types of columns: int, float, bool, list (list:; for other separator), ipv4net, date (date:02.01.2006 for other layout)
+#port_count = "int"
+
+# linters checking syntax of output files matching a pattern: junos, ios, eos, json, yaml
+#[[lint]]
+#files = "*.cfg"
+#linter = "ios"
+
+[serve]
+# addresses of HTTP and TFTP servers of 'serve' command, an empty one disables a server
+#http = "127.0.0.1:8080"
+#tftp = "127.0.0.1:6969"
+# columns matched with names requested by devices, output_column_name by default
+#match_columns = ["serial", "mac", "hostname"]
+# generate files on demand from the current data file
+#render = false
+
+[dhcp]
+# DHCP reservations pointing devices to their output files, generated when any of output files is set
+#kea = "dhcp/kea-reservations.json"
+#isc = "dhcp/dhcpd-hosts.conf"
+#hostname_column = "hostname"
+#mac_column = "mac"
+#ip_column = "ip"
+# sent as option 66 and 150, path of an output file prefixed with bootfile_prefix is sent as option 67
+#tftp_server = "10.0.0.10"
+#bootfile_prefix = ""
+
+[deploy]
+# generated files are pushed over SSH to devices named in host_column (output_column_name by default)
+#host_column = "mgmt_ip"
+#port = 22
+#user = "admin"
+# password is read from GOTMPL_SSH_PASSWORD environment variable
+#key_file = "/home/admin/.ssh/id_ed25519"
+#known_hosts = "/home/admin/.ssh/known_hosts"
+#insecure_host_key = false
+# scp uploads files to remote_path, paste types their lines between 'before' and 'after' commands
+#method = "scp"
+#remote_path = "flash:/"
+#before = ["configure terminal"]
+#after = ["end", "exit"]
+#concurrency = 5
+#timeout = "60s"
+#line_delay = "0s"
+
+[inventory]
+# devices exported by 'export inventory' are named by output_column_name
+#host_column = "mgmt_ip"
+#platform_column = "os"
+#group_columns = ["site", "role"]
+# columns exported as host variables, all but template and output columns by default
+#var_columns = ["site", "loopback"]
+# prepended to paths of output files in config_file, absolute path of output directory by default
+#config_prefix = ""
+
+# aggregate templates are executed once with all rows available as .Rows
+#[[aggregate]]
+#template = "inventory"
+#output = "hosts.ini"
+
+# profiles applied with --profile are merged over settings above, e.g. 'generate --profile prod'
+#[profiles.prod]
+#csv_data = "prod.csv"
+#[profiles.prod.vars]
+#customer = "ACME"
 `),
 		rootDir + "/" + name + "/README.md": []byte(`## Root of a workspace, workspace.toml configurations file should be placed here
 		`),
@@ -84,6 +169,10 @@ output_column_name = "hostname"
 		`),
 		rootDir + "/" + name + "/output/README.md": []byte(`## Place where all the generated files will be placed
 		`),
+		rootDir + "/" + name + "/running/README.md": []byte(`## Running configs captured from devices, compared with generated files by 'compare' command
+		`),
+		rootDir + "/" + name + "/logs/README.md": []byte(`## Transcripts of sessions of 'deploy' command
+		`),
 	}
 
 	_, err = os.Stat(rootDir + "/" + name)
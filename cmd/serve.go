@@ -0,0 +1,266 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pegaz/go-tmpl/text"
+	"github.com/pegaz/go-tmpl/ztp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	DefaultHTTPAddr = "127.0.0.1:8080"
+	DefaultTFTPAddr = "127.0.0.1:6969"
+)
+
+var (
+	httpAddr     string
+	tftpAddr     string
+	renderOnline bool
+	matchColumns []string
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve generated files over HTTP and TFTP for zero-touch provisioning",
+	Long: `Serve generated files of a workspace over HTTP and TFTP. A requested name (with or without extension) is matched
+with values of match columns (e.g. serial number, MAC address or hostname) of data file rows and the output file of
+the first matching row is served. Other names are matched with paths of output files. With --render files are
+generated on demand from the current data file instead of being read from output directory.`,
+
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		setDefaults()
+
+		err := initConfig()
+		if err != nil {
+			return err
+		}
+
+		// flags take precedence over configuration file
+		if !cmd.Flags().Changed("http") {
+			httpAddr = viper.GetString("serve.http")
+		}
+		if !cmd.Flags().Changed("tftp") {
+			tftpAddr = viper.GetString("serve.tftp")
+		}
+		if !cmd.Flags().Changed("render") {
+			renderOnline = viper.GetBool("serve.render")
+		}
+		matchColumns = viper.GetStringSlice("serve.match_columns")
+		if len(matchColumns) == 0 {
+			matchColumns = []string{outputColumnName}
+		}
+		if httpAddr == "" && tftpAddr == "" {
+			return fmt.Errorf("nothing to serve, both HTTP and TFTP are disabled")
+		}
+
+		resolve := (&servedWorkspace{}).resolve
+		log := func(format string, v ...interface{}) {
+			fmt.Printf(format+"\n", v...)
+		}
+
+		errs := make(chan error, 2)
+
+		if httpAddr != "" {
+			l, err := net.Listen("tcp", httpAddr)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("serving HTTP on %s\n", l.Addr())
+			go func() {
+				errs <- http.Serve(l, ztp.HTTPHandler(resolve, log))
+			}()
+		}
+
+		if tftpAddr != "" {
+			conn, err := net.ListenPacket("udp", tftpAddr)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("serving TFTP on %s\n", conn.LocalAddr())
+			go func() {
+				errs <- ztp.ServeTFTP(conn, resolve, log)
+			}()
+		}
+
+		return <-errs
+	},
+}
+
+// servedWorkspace holds data of a served workspace between requests. Data file is read again only when it's
+// modified, so requests are served from the current rows without reading them every time
+type servedWorkspace struct {
+	mu      sync.Mutex
+	wd      *workspaceData
+	modTime time.Time
+	size    int64
+	// outputs holds indexes of rows (in order of the data file) by paths of their output files
+	outputs map[string][]int
+}
+
+// load returns data of a workspace with an index of output files, both are loaded again when data file is modified
+func (sw *servedWorkspace) load() (*workspaceData, map[string][]int, error) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	fi, err := os.Stat(csvFilename)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sw.wd != nil && fi.ModTime().Equal(sw.modTime) && fi.Size() == sw.size {
+		return sw.wd, sw.outputs, nil
+	}
+
+	wd, err := loadData()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	outputs := make(map[string][]int)
+	for i, row := range wd.raw {
+		if row[templateColumnName] == "" || row[outputColumnName] == "" {
+			continue
+		}
+		_, outputName, err := wd.rowTemplate(i)
+		if err == nil {
+			outputs[outputName] = append(outputs[outputName], i)
+		}
+	}
+
+	sw.wd, sw.outputs, sw.modTime, sw.size = wd, outputs, fi.ModTime(), fi.Size()
+
+	return wd, outputs, nil
+}
+
+// resolve returns content and charset of an output file for a name requested by a device. Rendered file is built
+// from all rows appending to it in order of the data file, the same way generate does
+func (sw *servedWorkspace) resolve(name string) ([]byte, string, error) {
+	wd, outputs, err := sw.load()
+	if err != nil {
+		return nil, "", err
+	}
+
+	i := wd.match(name, outputs)
+	if i < 0 {
+		return nil, "", ztp.ErrNotFound
+	}
+
+	_, outputName, err := wd.rowTemplate(i)
+	if err != nil {
+		return nil, "", err
+	}
+	rows := outputs[outputName]
+	if len(rows) == 0 {
+		rows = []int{i}
+	}
+
+	// charset of a file is the one of its first row
+	tmpl, _, err := wd.rowTemplate(rows[0])
+	if err != nil {
+		return nil, "", err
+	}
+	charset := tmpl.Encoding().Charset
+
+	if !renderOnline {
+		content, err := ioutil.ReadFile(rootDir + "/" + workspaceName + directories["output"] + "/" + outputName)
+		if os.IsNotExist(err) {
+			return nil, "", ztp.ErrNotFound
+		}
+		return content, charset, err
+	}
+
+	var content []byte
+	for _, j := range rows {
+		tmpl, _, err := wd.rowTemplate(j)
+		if err != nil {
+			return nil, "", err
+		}
+
+		var output strings.Builder
+		err = tmpl.Execute(&output)
+		if err != nil {
+			return nil, "", err
+		}
+
+		e, err := tmpl.Encoding().Encode(output.String())
+		if err != nil {
+			return nil, "", err
+		}
+		content = append(content, e...)
+	}
+
+	return content, charset, nil
+}
+
+// match returns index of the first row with a value of a match column equal to a requested name (with or without
+// extension) or with an output file of a given path (looked up in outputs). MAC addresses are compared regardless
+// of their notation. It returns -1 when there is no such row
+func (wd *workspaceData) match(name string, outputs map[string][]int) int {
+	name = filepath.ToSlash(strings.TrimPrefix(name, "/"))
+	base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	// MAC address in dot notation looks like a name with an extension
+	mac, err := text.MAC(filepath.Base(name))
+	if err != nil {
+		mac, _ = text.MAC(base)
+	}
+
+	for i, row := range wd.raw {
+		for _, column := range matchColumns {
+			v := strings.TrimSpace(row[column])
+			if v == "" {
+				continue
+			}
+
+			if strings.EqualFold(v, name) || strings.EqualFold(v, base) {
+				return i
+			}
+			if m, err := text.MAC(v); mac != "" && err == nil && m == mac {
+				return i
+			}
+		}
+	}
+
+	if rows, ok := outputs[name]; ok {
+		return rows[0]
+	}
+
+	return -1
+}
+
+func init() {
+	serveCmd.Flags().StringVarP(&workspaceName, "name", "n", "", "workspace to serve files of")
+	serveCmd.MarkFlagRequired("name")
+	serveCmd.Flags().StringVarP(&workspaceConfig, "config", "c", "workspace.toml", "configuration file to use generator for")
+	serveCmd.Flags().StringSliceVar(&profiles, "profile", nil, "profiles of configuration file to apply, in order")
+	serveCmd.Flags().StringVar(&httpAddr, "http", DefaultHTTPAddr, "address of HTTP server, empty disables it")
+	serveCmd.Flags().StringVar(&tftpAddr, "tftp", DefaultTFTPAddr, "address of TFTP server, empty disables it")
+	serveCmd.Flags().BoolVar(&renderOnline, "render", false, "generate files on demand from the current data file")
+
+	rootCmd.AddCommand(serveCmd)
+}
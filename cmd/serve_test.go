@@ -0,0 +1,110 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestServedWorkspace(t *testing.T) {
+	defer setupWorkspace(t, map[string]string{
+		"workspace.toml":    "template_column_name = \"router\"\noutput_column_name = \"hostname\"\n",
+		"data/data.csv":     "hostname,router,serial\nr1,ios,FOC1\nr2,ios,FOC2\n",
+		"templates/ios.tpl": "{{/*---\npath: \"{{.router}}/{{.hostname}}\"\n---*/}}\nhostname {{.hostname}}\n",
+		"output/ios/r1.txt": "hostname r1\n",
+		"output/ios/r2.txt": "hostname r2\n",
+		"output/ios/r3.txt": "hostname r3\n",
+	})()
+
+	setDefaults()
+	if err := initConfig(); err != nil {
+		t.Fatal(err)
+	}
+	matchColumns = []string{"serial"}
+	renderOnline = false
+
+	sw := &servedWorkspace{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			content, _, err := sw.resolve("FOC2")
+			if err != nil || string(content) != "hostname r2\n" {
+				t.Errorf("expected to get output file of r2, instead got %q (error: %v)", content, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	wd, _, err := sw.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _, _ := sw.load(); again != wd {
+		t.Errorf("expected to get cached data of a workspace while data file isn't modified")
+	}
+
+	if _, _, err := sw.resolve("ios/r3.txt"); err == nil {
+		t.Errorf("expected to get no output file of a row which isn't in data file")
+	}
+
+	writeFiles(t, map[string]string{"data/data.csv": "hostname,router,serial\nr1,ios,FOC1\nr2,ios,FOC2\nr3,ios,FOC3\n"})
+
+	content, _, err := sw.resolve("ios/r3.txt")
+	if err != nil || string(content) != "hostname r3\n" {
+		t.Errorf("expected to get output file of r3 after data file is modified, instead got %q (error: %v)", content, err)
+	}
+}
+
+func TestServedWorkspaceRender(t *testing.T) {
+	defer setupWorkspace(t, map[string]string{
+		"workspace.toml":     "template_column_name = \"router\"\noutput_column_name = \"hostname\"\n",
+		"data/data.csv":      "hostname,router,serial\nsw1,ios,FOC1\nsw2,ios,FOC2\nsw1,vlan,FOC3\n",
+		"templates/ios.tpl":  "{{/*---\ncharset: iso-8859-2\n---*/}}\nhostname {{.hostname}}\n",
+		"templates/vlan.tpl": "vlan {{.serial}}\n",
+	})()
+
+	if err := generateCmd.RunE(generateCmd, nil); err != nil {
+		t.Fatal(err)
+	}
+	generated, err := ioutil.ReadFile(filepath.Join(rootDir, workspaceName, "output", "sw1.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matchColumns = []string{"serial"}
+	defer func() { renderOnline = false }()
+
+	for _, render := range []bool{false, true} {
+		renderOnline = render
+		sw := &servedWorkspace{}
+
+		// rows appending to the same file are served together, whichever of them is matched
+		for _, name := range []string{"FOC1", "FOC3"} {
+			content, charset, err := sw.resolve(name)
+			if err != nil || string(content) != string(generated) {
+				t.Errorf("expected to get %q for %s (render: %t), instead got %q (error: %v)", generated, name, render, content, err)
+			}
+			if charset != "iso-8859-2" {
+				t.Errorf("expected to get iso-8859-2 charset for %s (render: %t), instead got '%s'", name, render, charset)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/pegaz/go-tmpl/bundle"
+	"github.com/spf13/cobra"
+)
+
+var verifyKey string
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify <bundle>",
+	Short: "Verify a bundle against its manifest",
+	Long: `Verify checksums of files of a bundle made with 'generate --bundle' against its manifest. With --key signature
+of the manifest is checked with ed25519 public key (authorized_keys format, e.g. id_ed25519.pub) too.`,
+	Args: cobra.ExactArgs(1),
+
+	SilenceUsage: true,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var key ed25519.PublicKey
+		if verifyKey != "" {
+			var err error
+			key, err = bundle.ReadPublicKey(verifyKey)
+			if err != nil {
+				return err
+			}
+		}
+
+		b, err := bundle.Open(args[0])
+		if err != nil {
+			return err
+		}
+
+		m := b.Manifest
+		fmt.Printf("workspace %s, generated %s by go-tmpl %s\n", m.Workspace, m.Generated.Format("2006-01-02 15:04:05 MST"), m.Version)
+		fmt.Printf("data file %s (sha256 %s)\n", m.DataFile, m.DataSHA256)
+		if m.Commit != "" {
+			fmt.Printf("commit %s\n", m.Commit)
+		}
+		for _, f := range m.Files {
+			fmt.Printf("* %s", f.Path)
+			if f.Template != "" {
+				fmt.Printf(" (template %s", f.Template)
+				if len(f.Rows) > 0 {
+					fmt.Printf(", rows %v", f.Rows)
+				}
+				fmt.Print(")")
+			}
+			fmt.Println()
+		}
+		fmt.Println()
+
+		problems := b.Verify(key)
+		for _, problem := range problems {
+			fmt.Printf("verify: %s\n", problem)
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("bundle %s is invalid, %d problems found", args[0], len(problems))
+		}
+
+		if key != nil {
+			fmt.Printf("Bundle %s is valid and signed with the given key", args[0])
+		} else if b.Signature != nil {
+			fmt.Printf("Bundle %s is valid, its signature isn't checked without --key", args[0])
+		} else {
+			fmt.Printf("Bundle %s is valid", args[0])
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	verifyCmd.Flags().StringVar(&verifyKey, "key", "", "ed25519 public key to check signature of the manifest with")
+
+	rootCmd.AddCommand(verifyCmd)
+}
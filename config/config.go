@@ -0,0 +1,218 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config reads configuration files of a workspace: files may extend base ones, profiles defined
+// in [profiles.<name>] tables are layered on top of them and global variables may be overridden with environment
+// variables. An origin of every value is recorded, so the effective configuration may be explained
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml"
+)
+
+const (
+	// ExtendsKey names a base configuration file (relative to the extending one)
+	ExtendsKey = "extends"
+	// ProfilesKey names a table with profiles
+	ProfilesKey = "profiles"
+	// EnvVarPrefix is a prefix of environment variables overriding global variables, e.g. GOTMPL_VAR_customer
+	EnvVarPrefix = "GOTMPL_VAR_"
+)
+
+// Config is an effective configuration of a workspace
+type Config struct {
+	// Settings holds merged keys of configuration files, tables are nested maps. Keys are lower cased
+	Settings map[string]interface{}
+	// Origins holds a source of every value by its dotted key, e.g. 'vars.customer': a name of a file, a profile
+	// or an environment variable
+	Origins map[string]string
+}
+
+// Load reads a configuration file together with base files it extends, applies given profiles in order and
+// overrides global variables with environment variables (given as 'key=value' strings, as returned by os.Environ)
+func Load(path string, profiles []string, environ []string) (*Config, error) {
+	c := &Config{
+		Settings: make(map[string]interface{}),
+		Origins:  make(map[string]string),
+	}
+
+	err := c.load(path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defined, _ := c.Settings[ProfilesKey].(map[string]interface{})
+	for _, name := range profiles {
+		name = strings.ToLower(name)
+		profile, ok := defined[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("profile '%s' isn't defined in %s", name, filepath.Base(path))
+		}
+
+		origins := make(map[string]string)
+		for key, origin := range c.Origins {
+			origins[key] = origin
+		}
+		c.merge(c.Settings, profile, "", func(key string) string {
+			return fmt.Sprintf("profile %s (%s)", name, origins[ProfilesKey+"."+name+"."+key])
+		})
+	}
+
+	delete(c.Settings, ProfilesKey)
+	delete(c.Settings, ExtendsKey)
+	c.forget(ProfilesKey)
+	c.forget(ExtendsKey)
+
+	for _, env := range environ {
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) != 2 || !strings.HasPrefix(kv[0], EnvVarPrefix) || kv[0] == EnvVarPrefix {
+			continue
+		}
+
+		c.merge(c.Settings, map[string]interface{}{
+			"vars": map[string]interface{}{strings.ToLower(strings.TrimPrefix(kv[0], EnvVarPrefix)): kv[1]},
+		}, "", func(string) string { return "env " + kv[0] })
+	}
+
+	return c, nil
+}
+
+// load reads a configuration file after base files it extends. Files already being read are passed to detect cycles
+func (c *Config) load(path string, extending []string) error {
+	for _, p := range extending {
+		if p == path {
+			return fmt.Errorf("configuration file %s extends itself", filepath.Base(path))
+		}
+	}
+
+	tree, err := toml.LoadFile(path)
+	if err != nil {
+		return fmt.Errorf("can't read configuration file %s: %s", filepath.Base(path), err)
+	}
+	settings := lower(tree.ToMap())
+
+	if base, ok := settings[ExtendsKey]; ok {
+		name, ok := base.(string)
+		if !ok || name == "" {
+			return fmt.Errorf("'%s' in %s should be a name of a configuration file", ExtendsKey, filepath.Base(path))
+		}
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(filepath.Dir(path), name)
+		}
+
+		err = c.load(filepath.Clean(name), append(extending, path))
+		if err != nil {
+			return err
+		}
+	}
+
+	origin := filepath.Base(path)
+	c.merge(c.Settings, settings, "", func(string) string { return origin })
+
+	return nil
+}
+
+// merge deeply merges src into dst, tables are merged key by key while other values (arrays as well) replace
+// the ones of dst. Origins of merged values are set with a given function
+func (c *Config) merge(dst map[string]interface{}, src map[string]interface{}, prefix string, origin func(key string) string) {
+	for k, v := range src {
+		key := prefix + k
+
+		if table, ok := v.(map[string]interface{}); ok {
+			d, ok := dst[k].(map[string]interface{})
+			if !ok {
+				d = make(map[string]interface{})
+				dst[k] = d
+				delete(c.Origins, key)
+			}
+			c.merge(d, table, key+".", origin)
+			continue
+		}
+
+		dst[k] = v
+		c.forget(key)
+		c.Origins[key] = origin(key)
+	}
+}
+
+// forget removes origins of a key and its nested keys
+func (c *Config) forget(key string) {
+	for k := range c.Origins {
+		if k == key || strings.HasPrefix(k, key+".") {
+			delete(c.Origins, k)
+		}
+	}
+}
+
+// lower returns a copy of a map with lower cased keys of tables, like viper does
+func lower(m map[string]interface{}) map[string]interface{} {
+	l := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if table, ok := v.(map[string]interface{}); ok {
+			v = lower(table)
+		}
+		l[strings.ToLower(k)] = v
+	}
+
+	return l
+}
+
+// Format returns a value of configuration in TOML-like syntax
+func Format(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case []string:
+		values := make([]interface{}, len(v))
+		for i, s := range v {
+			values[i] = s
+		}
+		return Format(values)
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, value := range v {
+			values[i] = Format(value)
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	case []map[string]interface{}:
+		values := make([]interface{}, len(v))
+		for i, table := range v {
+			values[i] = table
+		}
+		return Format(values)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		pairs := make([]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = k + " = " + Format(v[k])
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var files = map[string]string{
+	"base.toml": `csv_delimiter = ";"
+template_column_name = "router"
+
+[vars]
+customer = "acme"
+domain = "lab.example.com"
+
+[profiles.prod.vars]
+domain = "example.com"
+`,
+	"workspace.toml": `extends = "base.toml"
+output_column_name = "hostname"
+delims = ["[[", "]]"]
+
+[vars]
+Site = "waw"
+
+[[aggregate]]
+template = "hosts"
+output = "inventory/hosts"
+
+[profiles.prod]
+csv_data = "prod.csv"
+
+[profiles.dr]
+csv_data = "dr.csv"
+
+[profiles.dr.vars]
+site = "krk"
+`,
+	"loop.toml":  `extends = "loop2.toml"`,
+	"loop2.toml": `extends = "loop.toml"`,
+}
+
+func setup(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestLoad(t *testing.T) {
+	dir, cleanup := setup(t)
+	defer cleanup()
+
+	var testCases = []struct {
+		name     string
+		profiles []string
+		environ  []string
+		values   map[string]string
+		origins  map[string]string
+	}{
+		{
+			name: "extends",
+			values: map[string]string{
+				"csv_delimiter":      `";"`,
+				"output_column_name": `"hostname"`,
+				"delims":             `["[[", "]]"]`,
+				"vars.customer":      `"acme"`,
+				"vars.domain":        `"lab.example.com"`,
+				"vars.site":          `"waw"`,
+				"aggregate":          `[{output = "inventory/hosts", template = "hosts"}]`,
+			},
+			origins: map[string]string{
+				"csv_delimiter":      "base.toml",
+				"output_column_name": "workspace.toml",
+				"vars.site":          "workspace.toml",
+			},
+		},
+		{
+			name:     "profiles",
+			profiles: []string{"prod", "DR"},
+			values: map[string]string{
+				"csv_data":      `"dr.csv"`,
+				"vars.customer": `"acme"`,
+				"vars.domain":   `"example.com"`,
+				"vars.site":     `"krk"`,
+			},
+			origins: map[string]string{
+				"csv_data":    "profile dr (workspace.toml)",
+				"vars.domain": "profile prod (base.toml)",
+				"vars.site":   "profile dr (workspace.toml)",
+			},
+		},
+		{
+			name:     "environment",
+			profiles: []string{"prod"},
+			environ:  []string{"GOTMPL_VAR_customer=umbrella", "GOTMPL_VAR_Vlan=10", "GOTMPL_VAR_=x", "HOME=/root"},
+			values: map[string]string{
+				"vars.customer": `"umbrella"`,
+				"vars.vlan":     `"10"`,
+				"vars.domain":   `"example.com"`,
+			},
+			origins: map[string]string{
+				"vars.customer": "env GOTMPL_VAR_customer",
+				"vars.vlan":     "env GOTMPL_VAR_Vlan",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		c, err := Load(filepath.Join(dir, "workspace.toml"), tc.profiles, tc.environ)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.name, err)
+		}
+
+		for key, expected := range tc.values {
+			if got := Format(get(c.Settings, key)); got != expected {
+				t.Errorf("%s: expected to get %s for %s, instead got %s", tc.name, expected, key, got)
+			}
+		}
+		for key, expected := range tc.origins {
+			if got := c.Origins[key]; got != expected {
+				t.Errorf("%s: expected to get origin '%s' of %s, instead got '%s'", tc.name, expected, key, got)
+			}
+		}
+
+		for _, key := range []string{ExtendsKey, ProfilesKey} {
+			if _, ok := c.Settings[key]; ok {
+				t.Errorf("%s: expected not to get '%s' in effective configuration", tc.name, key)
+			}
+		}
+		for key := range c.Origins {
+			if strings.HasPrefix(key, ProfilesKey+".") {
+				t.Errorf("%s: expected not to get origin of %s", tc.name, key)
+			}
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir, cleanup := setup(t)
+	defer cleanup()
+
+	var testCases = []struct {
+		file     string
+		profiles []string
+		expected string
+	}{
+		{"loop.toml", nil, "extends itself"},
+		{"workspace.toml", []string{"staging"}, "profile 'staging' isn't defined"},
+		{"missing.toml", nil, "can't read configuration file missing.toml"},
+	}
+
+	for _, tc := range testCases {
+		_, err := Load(filepath.Join(dir, tc.file), tc.profiles, nil)
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("expected to get an error with '%s' for %s, instead got: %v", tc.expected, tc.file, err)
+		}
+	}
+}
+
+// get returns a value of a dotted key
+func get(settings map[string]interface{}, key string) interface{} {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		settings, _ = settings[part].(map[string]interface{})
+	}
+
+	return settings[parts[len(parts)-1]]
+}
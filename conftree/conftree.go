@@ -0,0 +1,129 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conftree parses indentation-based configurations (Cisco IOS, Arista EOS and alike) into trees of sections
+// and compares them regardless of order of sections and whitespaces
+package conftree
+
+import (
+	"strings"
+)
+
+// noise are prefixes of lines which aren't a part of configuration, e.g. headers of 'show running-config'
+var noise = []string{
+	"Building configuration",
+	"Current configuration",
+	"Last configuration change",
+	"NVRAM config last updated",
+	"! Command: show running-config",
+}
+
+// Node is a line of configuration with lines of its section
+type Node struct {
+	Line     string
+	Children []*Node
+}
+
+// child returns a child with a given line or nil
+func (n *Node) child(line string) *Node {
+	for _, c := range n.Children {
+		if c.Line == line {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// Parse parses configuration into a tree. The root node has an empty line and top level lines as children. Blank lines,
+// comments ('!'), 'end' and headers of 'show running-config' are skipped, whitespaces inside of lines are normalized
+func Parse(content string) *Node {
+	root := &Node{}
+	// stack holds enclosing sections with their indentation
+	type level struct {
+		indent int
+		node   *Node
+	}
+	stack := []level{{-1, root}}
+
+	var banner string
+	for _, line := range strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if banner != "" {
+			// lines of a banner are kept as they are within banner's node
+			last := stack[len(stack)-1].node
+			last.Children = append(last.Children, &Node{Line: strings.TrimRight(line, " \t")})
+			if strings.Contains(line, banner) {
+				banner = ""
+			}
+			continue
+		}
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "!") || trimmed == "end" || isNoise(trimmed) {
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		for stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		parent := stack[len(stack)-1].node
+		line = strings.Join(strings.Fields(trimmed), " ")
+
+		node := parent.child(line)
+		if node == nil {
+			node = &Node{Line: line}
+			parent.Children = append(parent.Children, node)
+		}
+		stack = append(stack, level{indent, node})
+
+		// lines of a multiline banner are added to its node regardless of their indentation
+		banner = bannerDelim(line)
+	}
+
+	return root
+}
+
+// isNoise reports whether line isn't a part of configuration
+func isNoise(line string) bool {
+	for _, prefix := range noise {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bannerDelim returns delimiter of a multiline banner starting in a given line or an empty string
+func bannerDelim(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || fields[0] != "banner" {
+		return ""
+	}
+
+	delim := fields[2][:1]
+	if strings.HasPrefix(fields[2], "^C") {
+		delim = "^C"
+	}
+
+	// banner ending in its first line
+	if strings.Contains(strings.SplitN(line, delim, 2)[1], delim) {
+		return ""
+	}
+
+	return delim
+}
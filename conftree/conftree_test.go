@@ -0,0 +1,167 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conftree
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var running = `Building configuration...
+
+Current configuration : 1024 bytes
+!
+hostname r1
+!
+banner motd ^C
+  Authorized access only
+^C
+interface Gi0/1
+ description  old uplink
+ shutdown
+!
+interface Gi0/2
+ no ip address
+!
+router bgp 65000
+ neighbor 10.0.0.2 remote-as 65000
+ address-family ipv4
+  network 10.1.0.0 mask 255.255.0.0
+ exit-address-family
+!
+snmp-server community public RO
+end
+`
+
+var generated = `hostname r1
+!
+router bgp 65000
+ address-family ipv4
+  network 10.1.0.0 mask 255.255.0.0
+  network 10.2.0.0 mask 255.255.0.0
+ exit-address-family
+ neighbor 10.0.0.2 remote-as 65000
+!
+interface Gi0/2
+ no ip address
+interface Gi0/1
+ description uplink
+!
+banner motd ^C
+  Authorized access only
+^C
+ntp server 10.0.0.1
+`
+
+func TestParse(t *testing.T) {
+	root := Parse(running)
+
+	var lines []string
+	for _, n := range root.Children {
+		lines = append(lines, n.Line)
+	}
+
+	expected := []string{"hostname r1", "banner motd ^C", "interface Gi0/1", "interface Gi0/2", "router bgp 65000", "snmp-server community public RO"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected to get %v, instead got %v", expected, lines)
+	}
+
+	if desc := root.Children[2].Children[0].Line; desc != "description old uplink" {
+		t.Errorf("expected to get 'description old uplink', instead got '%s'", desc)
+	}
+	if banner := root.Children[1].Children; len(banner) != 2 || banner[0].Line != "  Authorized access only" {
+		t.Errorf("expected to get lines of banner, instead got %v", banner)
+	}
+}
+
+func TestReport(t *testing.T) {
+	changes := Diff(Parse(generated), Parse(running))
+
+	expected := `- snmp-server community public RO
+  router bgp 65000
+    address-family ipv4
++     network 10.2.0.0 mask 255.255.0.0
+  interface Gi0/1
+-   description old uplink
+-   shutdown
++   description uplink
++ ntp server 10.0.0.1`
+
+	if result := strings.Join(Report(changes), "\n"); result != expected {
+		t.Errorf("expected to get:\n%s\ninstead got:\n%s", expected, result)
+	}
+}
+
+func TestRemediation(t *testing.T) {
+	changes := Diff(Parse(generated), Parse(running))
+
+	expected := `no snmp-server community public RO
+router bgp 65000
+ address-family ipv4
+  network 10.2.0.0 mask 255.255.0.0
+  exit-address-family
+ exit
+interface Gi0/1
+ no description old uplink
+ no shutdown
+ description uplink
+ exit
+ntp server 10.0.0.1`
+
+	if result := strings.Join(Remediation(changes), "\n"); result != expected {
+		t.Errorf("expected to get:\n%s\ninstead got:\n%s", expected, result)
+	}
+
+	if len(Diff(Parse(running), Parse(running))) != 0 {
+		t.Error("expected to get no changes of the same configuration")
+	}
+}
+
+func TestRemediationExit(t *testing.T) {
+	running := `router bgp 65000
+ neighbor 10.0.0.2 remote-as 65000
+ address-family ipv4
+  network 10.1.0.0 mask 255.255.0.0
+ exit-address-family
+ address-family ipv6
+ exit-address-family
+`
+	generated := `router bgp 65000
+ address-family ipv4
+  network 10.1.0.0 mask 255.255.0.0
+  network 10.2.0.0 mask 255.255.0.0
+ exit-address-family
+ address-family ipv6
+  network 2001:db8::/32
+ exit-address-family
+ neighbor 10.0.0.2 remote-as 65000
+ neighbor 10.0.0.3 remote-as 65001
+`
+
+	expected := `router bgp 65000
+ address-family ipv4
+  network 10.2.0.0 mask 255.255.0.0
+  exit-address-family
+ address-family ipv6
+  network 2001:db8::/32
+  exit-address-family
+ neighbor 10.0.0.3 remote-as 65001`
+
+	changes := Diff(Parse(generated), Parse(running))
+	if result := strings.Join(Remediation(changes), "\n"); result != expected {
+		t.Errorf("expected to get:\n%s\ninstead got:\n%s", expected, result)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conftree
+
+import (
+	"strings"
+)
+
+// Change is a line added to or removed from a section of configuration
+type Change struct {
+	// Path holds lines of enclosing sections, it is empty for top level lines
+	Path []string
+	// Node is the line with its section (if any)
+	Node *Node
+	// Added is true for lines present in the generated configuration only and false for lines present in the running one only
+	Added bool
+}
+
+// Diff returns differences between the running and generated configuration. Lines are compared within sections
+// regardless of their order, changes of a section are listed under it
+func Diff(generated *Node, running *Node) []Change {
+	return diff(nil, generated, running)
+}
+
+func diff(path []string, generated *Node, running *Node) []Change {
+	var changes []Change
+
+	for _, r := range running.Children {
+		if generated.child(r.Line) == nil {
+			changes = append(changes, Change{Path: path, Node: r})
+		}
+	}
+
+	for _, g := range generated.Children {
+		r := running.child(g.Line)
+		if r == nil {
+			changes = append(changes, Change{Path: path, Node: g, Added: true})
+			continue
+		}
+
+		sub := append(append([]string{}, path...), g.Line)
+		changes = append(changes, diff(sub, g, r)...)
+	}
+
+	return changes
+}
+
+// Report returns changes grouped by sections: lines of enclosing sections are followed by added ('+') and removed ('-')
+// lines, every level of sections is indented with two spaces
+func Report(changes []Change) []string {
+	var lines []string
+	var current []string
+
+	for _, c := range changes {
+		for i := commonLen(current, c.Path); i < len(c.Path); i++ {
+			lines = append(lines, "  "+strings.Repeat("  ", i)+c.Path[i])
+		}
+		current = c.Path
+
+		sign := "- "
+		if c.Added {
+			sign = "+ "
+		}
+		lines = append(lines, subtree(c.Node, sign+strings.Repeat("  ", len(c.Path)), "  ")...)
+	}
+
+	return lines
+}
+
+// Remediation returns commands which turn the running configuration into the generated one: enclosing sections are
+// entered, removed lines are negated with 'no' (or 'no' is stripped from them) and added lines are configured with
+// their sections. Sections are left with 'exit' ('exit-address-family' for address families) before lines of their
+// parents are configured. Removals precede additions within a section
+func Remediation(changes []Change) []string {
+	var lines []string
+	var current []string
+
+	for _, group := range groupByPath(changes) {
+		path := group[0].Path
+		for i := len(current) - 1; i >= commonLen(current, path); i-- {
+			lines = append(lines, strings.Repeat(" ", i+1)+exit(current[i]))
+		}
+		// sections already entered are not repeated
+		for i := commonLen(current, path); i < len(path); i++ {
+			lines = append(lines, strings.Repeat(" ", i)+path[i])
+		}
+		current = path
+
+		indent := strings.Repeat(" ", len(path))
+
+		for _, c := range group {
+			if !c.Added {
+				lines = append(lines, indent+negate(c.Node.Line))
+			}
+		}
+		for _, c := range group {
+			if c.Added {
+				lines = append(lines, subtree(c.Node, indent, " ")...)
+			}
+		}
+	}
+
+	return lines
+}
+
+// groupByPath groups consecutive changes of the same section
+func groupByPath(changes []Change) [][]Change {
+	var groups [][]Change
+
+	for _, c := range changes {
+		last := len(groups) - 1
+		if last >= 0 && commonLen(groups[last][0].Path, c.Path) == len(c.Path) && len(groups[last][0].Path) == len(c.Path) {
+			groups[last] = append(groups[last], c)
+		} else {
+			groups = append(groups, []Change{c})
+		}
+	}
+
+	return groups
+}
+
+// exit returns a command leaving a given section
+func exit(section string) string {
+	if strings.HasPrefix(section, "address-family ") {
+		return "exit-address-family"
+	}
+
+	return "exit"
+}
+
+// negate returns a command removing a given line
+func negate(line string) string {
+	if strings.HasPrefix(line, "no ") {
+		return strings.TrimPrefix(line, "no ")
+	}
+
+	return "no " + line
+}
+
+// subtree returns line of a node followed by lines of its section indented with a given unit
+func subtree(n *Node, prefix string, unit string) []string {
+	lines := []string{prefix + n.Line}
+	for _, c := range n.Children {
+		lines = append(lines, subtree(c, prefix+unit, unit)...)
+	}
+
+	return lines
+}
+
+// commonLen returns length of a common prefix of two paths
+func commonLen(a, b []string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+
+	return n
+}
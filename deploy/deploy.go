@@ -0,0 +1,361 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deploy pushes generated files to devices over SSH, either by uploading them with SCP or by pasting
+// their lines into an interactive session
+package deploy
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Methods of pushing a file to a device
+const (
+	MethodSCP   = "scp"
+	MethodPaste = "paste"
+)
+
+// DefaultTimeout limits time a push may wait for a device when no timeout is set
+const DefaultTimeout = 60 * time.Second
+
+// chunkSize is a size of chunks a file is written in, so a slow upload of a big file is still a progress
+const chunkSize = 32 * 1024
+
+// Options describe how files are pushed to devices
+type Options struct {
+	// Method is either MethodSCP or MethodPaste
+	Method string
+	// RemotePath is a path of an uploaded file on a device, a name of a file is appended when it ends with '/'.
+	// Name of a file is used when it is empty
+	RemotePath string
+	// Before and After are commands sent before and after pasted lines, e.g. 'configure terminal' and 'end'.
+	// The last command should close the session (e.g. 'exit'), otherwise it is closed when timeout is reached
+	Before []string
+	After  []string
+	// LineDelay is a pause after every pasted line for devices with small input buffers
+	LineDelay time.Duration
+	// Timeout limits time without any progress of a push: neither output of a device nor data sent to it.
+	// It also limits time of connecting to a device
+	Timeout time.Duration
+	// Config holds credentials and host key callback of SSH connections
+	Config *ssh.ClientConfig
+}
+
+// Check checks if options are valid
+func (o Options) Check() error {
+	switch o.Method {
+	case MethodSCP, MethodPaste:
+	default:
+		return fmt.Errorf("unknown method '%s', expected one of: %s, %s", o.Method, MethodSCP, MethodPaste)
+	}
+
+	if o.Config == nil {
+		return fmt.Errorf("no SSH client configuration")
+	}
+	if o.Timeout < 0 || o.LineDelay < 0 {
+		return fmt.Errorf("timeout and line delay can't be negative")
+	}
+
+	return nil
+}
+
+// Job is a single file pushed to a device
+type Job struct {
+	// Addr is an address of a device in host:port form
+	Addr string
+	// Name is a path of a file, its last element is used as a name of an uploaded file
+	Name    string
+	Content []byte
+	// Transcript receives output of a session, it may be nil
+	Transcript io.Writer
+}
+
+// Push connects to a device and pushes a file to it with a method set in options
+func Push(job Job, opts Options) error {
+	err := opts.Check()
+	if err != nil {
+		return err
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	var transcript io.Writer = ioutil.Discard
+	if job.Transcript != nil {
+		// output and error streams of a session are copied concurrently
+		transcript = &syncWriter{w: job.Transcript}
+	}
+	act := make(activity, 1)
+	transcript = activityWriter{w: transcript, a: act}
+
+	config := *opts.Config
+	if config.Timeout == 0 {
+		config.Timeout = timeout
+	}
+
+	client, err := ssh.Dial("tcp", job.Addr, &config)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		if opts.Method == MethodSCP {
+			done <- upload(session, job, opts.RemotePath, transcript, act)
+		} else {
+			done <- paste(session, job, opts, transcript, act)
+		}
+	}()
+
+	idle := time.NewTimer(timeout)
+	defer idle.Stop()
+
+	for {
+		select {
+		case err = <-done:
+			return err
+		case <-act:
+			if !idle.Stop() {
+				select {
+				case <-idle.C:
+				default:
+				}
+			}
+			idle.Reset(timeout)
+		case <-idle.C:
+			// closing the connection unblocks the push, it's waited for, so it doesn't write to the transcript
+			// after returning
+			client.Close()
+			<-done
+			return fmt.Errorf("timeout of %s reached without any progress", timeout)
+		}
+	}
+}
+
+// activity signals progress of a push, so it's aborted only when a device doesn't respond for a timeout
+type activity chan struct{}
+
+func (a activity) touch() {
+	select {
+	case a <- struct{}{}:
+	default:
+	}
+}
+
+// activityWriter signals activity on every chunk written to w
+type activityWriter struct {
+	w io.Writer
+	a activity
+}
+
+func (aw activityWriter) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+
+		aw.a.touch()
+		n, err := aw.w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+
+	return written, nil
+}
+
+// syncWriter serializes writes to a transcript
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.w.Write(p)
+}
+
+// remotePath returns a path of an uploaded file on a device
+func remotePath(remote string, name string) string {
+	base := path.Base(strings.Replace(name, "\\", "/", -1))
+	if remote == "" {
+		return base
+	}
+	if strings.HasSuffix(remote, "/") {
+		return remote + base
+	}
+
+	return remote
+}
+
+// upload sends a file with SCP protocol (as 'scp -t' sink expects it)
+func upload(session *ssh.Session, job Job, remote string, transcript io.Writer, act activity) error {
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	session.Stderr = transcript
+	acks := bufio.NewReader(stdout)
+	in := activityWriter{w: stdin, a: act}
+
+	target := remotePath(remote, job.Name)
+	fmt.Fprintf(transcript, "scp -t %s\n", target)
+	err = session.Start("scp -t " + quote(target))
+	if err != nil {
+		return err
+	}
+
+	steps := []struct {
+		send []byte
+		desc string
+	}{
+		{nil, "scp sink started"},
+		{[]byte(fmt.Sprintf("C0644 %d %s\n", len(job.Content), path.Base(target))), fmt.Sprintf("C0644 %d %s", len(job.Content), path.Base(target))},
+		{append(append([]byte{}, job.Content...), 0), fmt.Sprintf("%d bytes sent", len(job.Content))},
+	}
+	for _, step := range steps {
+		if step.send != nil {
+			_, err = in.Write(step.send)
+			if err != nil {
+				return err
+			}
+		}
+		err = readAck(acks)
+		if err != nil {
+			return fmt.Errorf("scp: %s", err)
+		}
+		fmt.Fprintf(transcript, "%s: ok\n", step.desc)
+	}
+
+	stdin.Close()
+
+	return session.Wait()
+}
+
+// readAck reads a response of SCP sink: zero byte or an error message
+func readAck(r *bufio.Reader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b == 0 {
+		return nil
+	}
+
+	msg, _ := r.ReadString('\n')
+	return fmt.Errorf("%s", strings.TrimSpace(msg))
+}
+
+// quote quotes a path for a remote shell
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// paste sends lines of a file to an interactive session as if they were typed in a terminal
+func paste(session *ssh.Session, job Job, opts Options, transcript io.Writer, act activity) error {
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	in := activityWriter{w: stdin, a: act}
+	session.Stdout = transcript
+	session.Stderr = transcript
+
+	modes := ssh.TerminalModes{ssh.ECHO: 1}
+	err = session.RequestPty("vt100", 0, 512, modes)
+	if err != nil {
+		return err
+	}
+	err = session.Shell()
+	if err != nil {
+		return err
+	}
+
+	lines := append([]string{}, opts.Before...)
+	content := strings.Replace(string(job.Content), "\r\n", "\n", -1)
+	lines = append(lines, strings.Split(strings.TrimSuffix(content, "\n"), "\n")...)
+	lines = append(lines, opts.After...)
+
+	for _, line := range lines {
+		_, err = io.WriteString(in, line+"\n")
+		if err != nil {
+			return err
+		}
+		if opts.LineDelay > 0 {
+			time.Sleep(opts.LineDelay)
+		}
+	}
+	stdin.Close()
+
+	err = session.Wait()
+	// devices rarely send exit status of a shell
+	if _, ok := err.(*ssh.ExitMissingError); ok {
+		return nil
+	}
+
+	return err
+}
+
+// Run pushes files of all jobs with at most concurrency pushes at once and returns their errors in order of jobs
+func Run(jobs []Job, opts Options, concurrency int) []error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	errs := make([]error, len(jobs))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i := range jobs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			errs[i] = Push(jobs[i], opts)
+		}(i)
+	}
+	wg.Wait()
+
+	return errs
+}
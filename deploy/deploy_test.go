@@ -0,0 +1,374 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testServer is an in-process SSH server standing in for a device. It accepts uploads of 'scp -t' sink and records
+// lines typed into a shell, the shell is closed with 'exit' command
+type testServer struct {
+	addr   string
+	signer ssh.Signer
+
+	mu     sync.Mutex
+	files  map[string][]byte
+	lines  []string
+	active int
+	max    int
+}
+
+func newTestServer(t *testing.T) *testServer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if c.User() == "admin" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("access denied for %s", c.User())
+		},
+	}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &testServer{addr: l.Addr().String(), signer: signer, files: make(map[string][]byte)}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn, config)
+		}
+	}()
+
+	return s
+}
+
+func (s *testServer) handle(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newCh := range chans {
+		if newCh.ChannelType() != "session" {
+			newCh.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		ch, requests, err := newCh.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			for req := range requests {
+				switch req.Type {
+				case "pty-req":
+					req.Reply(true, nil)
+				case "shell":
+					req.Reply(true, nil)
+					go s.shell(ch)
+				case "exec":
+					var payload struct{ Command string }
+					ssh.Unmarshal(req.Payload, &payload)
+					req.Reply(true, nil)
+					go s.sink(ch, payload.Command)
+				default:
+					req.Reply(false, nil)
+				}
+			}
+		}()
+	}
+}
+
+func (s *testServer) enter() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.active++
+	if s.active > s.max {
+		s.max = s.active
+	}
+}
+
+func (s *testServer) leave(ch ssh.Channel, status uint32) {
+	s.mu.Lock()
+	s.active--
+	s.mu.Unlock()
+
+	ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+	ch.Close()
+}
+
+// shell echoes lines like a terminal of a device, it doesn't end a session on EOF
+func (s *testServer) shell(ch ssh.Channel) {
+	s.enter()
+
+	scanner := bufio.NewScanner(ch)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fmt.Fprintf(ch, "%s\r\n", line)
+
+		s.mu.Lock()
+		s.lines = append(s.lines, line)
+		s.mu.Unlock()
+
+		if line == "exit" {
+			s.leave(ch, 0)
+			return
+		}
+	}
+}
+
+// sink receives a single file like 'scp -t' does, targets with 'denied' in their paths are refused
+func (s *testServer) sink(ch ssh.Channel, command string) {
+	s.enter()
+
+	target := strings.Trim(strings.TrimPrefix(command, "scp -t "), "'")
+	if strings.Contains(target, "denied") {
+		fmt.Fprintf(ch, "\x01scp: %s: permission denied\n", target)
+		s.leave(ch, 1)
+		return
+	}
+	ch.Write([]byte{0})
+
+	r := bufio.NewReader(ch)
+	header, err := r.ReadString('\n')
+	if err != nil {
+		s.leave(ch, 1)
+		return
+	}
+	fields := strings.Fields(header)
+	size, _ := strconv.Atoi(fields[1])
+	ch.Write([]byte{0})
+
+	content := make([]byte, size+1)
+	_, err = io.ReadFull(r, content)
+	if err != nil {
+		s.leave(ch, 1)
+		return
+	}
+
+	s.mu.Lock()
+	s.files[target] = content[:size]
+	s.mu.Unlock()
+
+	// pushes take a while, so concurrent ones overlap
+	time.Sleep(50 * time.Millisecond)
+	ch.Write([]byte{0})
+	s.leave(ch, 0)
+}
+
+func (s *testServer) options(method string) Options {
+	return Options{
+		Method:  method,
+		Timeout: 5 * time.Second,
+		Config: &ssh.ClientConfig{
+			User:            "admin",
+			Auth:            []ssh.AuthMethod{ssh.Password("secret")},
+			HostKeyCallback: ssh.FixedHostKey(s.signer.PublicKey()),
+		},
+	}
+}
+
+func TestPushSCP(t *testing.T) {
+	s := newTestServer(t)
+	content := []byte("hostname r1\n!\n")
+
+	var testCases = []struct {
+		remote   string
+		expected string
+	}{
+		{"", "r1.cfg"},
+		{"flash:/", "flash:/r1.cfg"},
+		{"/config/juniper.conf", "/config/juniper.conf"},
+	}
+
+	for _, tc := range testCases {
+		opts := s.options(MethodSCP)
+		opts.RemotePath = tc.remote
+
+		var transcript bytes.Buffer
+		err := Push(Job{Addr: s.addr, Name: "waw/r1.cfg", Content: content, Transcript: &transcript}, opts)
+		if err != nil {
+			t.Fatalf("expected to upload to '%s' without errors, instead got: %s", tc.remote, err)
+		}
+
+		if got := s.files[tc.expected]; !bytes.Equal(got, content) {
+			t.Errorf("expected to get '%s' uploaded to %s, instead got '%s'", content, tc.expected, got)
+		}
+		if !strings.Contains(transcript.String(), "C0644 14 ") {
+			t.Errorf("expected to get upload in transcript, instead got '%s'", transcript.String())
+		}
+	}
+}
+
+func TestPushSCPRefused(t *testing.T) {
+	s := newTestServer(t)
+	opts := s.options(MethodSCP)
+	opts.RemotePath = "denied/"
+
+	err := Push(Job{Addr: s.addr, Name: "r1.cfg", Content: []byte("hostname r1\n")}, opts)
+	if err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("expected to get 'permission denied' error, instead got: %v", err)
+	}
+}
+
+func TestPushPaste(t *testing.T) {
+	s := newTestServer(t)
+	opts := s.options(MethodPaste)
+	opts.Before = []string{"configure terminal"}
+	opts.After = []string{"end", "exit"}
+
+	var transcript bytes.Buffer
+	err := Push(Job{Addr: s.addr, Name: "r1.cfg", Content: []byte("hostname r1\r\ninterface lo0\r\n"), Transcript: &transcript}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"configure terminal", "hostname r1", "interface lo0", "end", "exit"}
+	if !reflect.DeepEqual(s.lines, expected) {
+		t.Errorf("expected to get lines %q pasted, instead got %q", expected, s.lines)
+	}
+	if !strings.Contains(transcript.String(), "interface lo0\r\n") {
+		t.Errorf("expected to get echoed lines in transcript, instead got '%s'", transcript.String())
+	}
+}
+
+func TestPushTimeout(t *testing.T) {
+	s := newTestServer(t)
+	opts := s.options(MethodPaste)
+	opts.Timeout = 200 * time.Millisecond
+
+	// without 'exit' the session is never closed by a device
+	var transcript bytes.Buffer
+	err := Push(Job{Addr: s.addr, Name: "r1.cfg", Content: []byte("hostname r1\n"), Transcript: &transcript}, opts)
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("expected to get timeout error, instead got: %v", err)
+	}
+
+	// session is finished before Push returns, so nothing is written to the transcript afterwards
+	n := transcript.Len()
+	time.Sleep(100 * time.Millisecond)
+	if transcript.Len() != n {
+		t.Errorf("expected to get no output in transcript after timeout, instead got '%s'", transcript.String())
+	}
+}
+
+func TestPushIdleTimeout(t *testing.T) {
+	s := newTestServer(t)
+	opts := s.options(MethodPaste)
+	opts.Timeout = 300 * time.Millisecond
+	opts.LineDelay = 100 * time.Millisecond
+	opts.After = []string{"exit"}
+
+	// pasting takes longer than timeout, but every line is a progress
+	err := Push(Job{Addr: s.addr, Name: "r1.cfg", Content: []byte("a\nb\nc\nd\ne\nf\n")}, opts)
+	if err != nil {
+		t.Errorf("expected to get a slow push without errors, instead got: %s", err)
+	}
+}
+
+func TestPushAuthFailure(t *testing.T) {
+	s := newTestServer(t)
+	opts := s.options(MethodSCP)
+	opts.Config.Auth = []ssh.AuthMethod{ssh.Password("wrong")}
+
+	err := Push(Job{Addr: s.addr, Name: "r1.cfg", Content: []byte("hostname r1\n")}, opts)
+	if err == nil {
+		t.Errorf("expected to get an error for wrong password, instead got nil")
+	}
+}
+
+func TestRun(t *testing.T) {
+	s := newTestServer(t)
+	opts := s.options(MethodSCP)
+
+	var jobs []Job
+	for i := 1; i <= 6; i++ {
+		jobs = append(jobs, Job{Addr: s.addr, Name: fmt.Sprintf("r%d.cfg", i), Content: []byte(fmt.Sprintf("hostname r%d\n", i))})
+	}
+	jobs = append(jobs, Job{Addr: "127.0.0.1:1", Name: "r7.cfg", Content: []byte("hostname r7\n")})
+
+	errs := Run(jobs, opts, 2)
+
+	for i, err := range errs[:6] {
+		if err != nil {
+			t.Errorf("expected to push %s without errors, instead got: %s", jobs[i].Name, err)
+		}
+	}
+	if errs[6] == nil {
+		t.Errorf("expected to get an error for unreachable device, instead got nil")
+	}
+	if len(s.files) != 6 {
+		t.Errorf("expected to get 6 files uploaded, instead got %d", len(s.files))
+	}
+	if s.max > 2 {
+		t.Errorf("expected to get at most 2 concurrent pushes, instead got %d", s.max)
+	}
+}
+
+func TestOptionsCheck(t *testing.T) {
+	config := &ssh.ClientConfig{}
+
+	var testCases = []struct {
+		opts  Options
+		valid bool
+	}{
+		{Options{Method: MethodSCP, Config: config}, true},
+		{Options{Method: MethodPaste, Config: config, LineDelay: time.Millisecond}, true},
+		{Options{Method: "ftp", Config: config}, false},
+		{Options{Method: MethodSCP}, false},
+		{Options{Method: MethodSCP, Config: config, Timeout: -time.Second}, false},
+	}
+
+	for _, tc := range testCases {
+		err := tc.opts.Check()
+		if (err == nil) != tc.valid {
+			t.Errorf("expected validity of %+v to be %t, instead got error: %v", tc.opts, tc.valid, err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dhcp renders DHCP reservations of devices pointing them to their config files for zero-touch provisioning,
+// both for ISC dhcpd and Kea DHCPv4 servers
+package dhcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Host is a reservation of a single device
+type Host struct {
+	Hostname string
+	// MAC address in aa:bb:cc:dd:ee:ff notation
+	MAC string
+	IP  string
+	// BootFile is a name (or URL) of a config file of a device
+	BootFile string
+}
+
+// reHostname matches a label of a hostname (RFC 1123): letters, digits and hyphens, not beginning or ending with a hyphen
+var reHostname = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+// Check checks if a reservation is valid: hostname consists of RFC 1123 labels, IP is an IPv4 address and
+// boot file may be quoted
+func (h Host) Check() error {
+	if len(h.Hostname) > 253 {
+		return fmt.Errorf("hostname %q is longer than 253 characters", h.Hostname)
+	}
+	for _, label := range strings.Split(h.Hostname, ".") {
+		if !reHostname.MatchString(label) {
+			return fmt.Errorf("invalid hostname %q, expected letters, digits and hyphens separated with dots", h.Hostname)
+		}
+	}
+
+	if ip := net.ParseIP(h.IP); ip == nil || ip.To4() == nil {
+		return fmt.Errorf("IP address of %s should be an IPv4 address, got: %q", h.Hostname, h.IP)
+	}
+
+	if strings.ContainsAny(h.BootFile, "\"\\\r\n") {
+		return fmt.Errorf("boot file of %s contains a quote, a backslash or a new line: %q", h.Hostname, h.BootFile)
+	}
+
+	return nil
+}
+
+// Options are DHCP options common for all reservations
+type Options struct {
+	// TFTPServer is an IPv4 address of TFTP server sent as option 66 (tftp-server-name) and 150 (Cisco TFTP server),
+	// options are omitted when it is empty
+	TFTPServer string
+}
+
+// Check checks if options are valid
+func (o Options) Check() error {
+	if o.TFTPServer != "" && net.ParseIP(o.TFTPServer).To4() == nil {
+		return fmt.Errorf("TFTP server should be an IPv4 address, got: %s", o.TFTPServer)
+	}
+
+	return nil
+}
+
+// keaOption is an element of 'option-data' list of Kea
+type keaOption struct {
+	Name      string `json:"name,omitempty"`
+	Code      int    `json:"code,omitempty"`
+	CSVFormat *bool  `json:"csv-format,omitempty"`
+	Data      string `json:"data"`
+}
+
+// keaReservation is a host reservation of Kea
+type keaReservation struct {
+	Hostname   string      `json:"hostname"`
+	HWAddress  string      `json:"hw-address"`
+	IPAddress  string      `json:"ip-address"`
+	OptionData []keaOption `json:"option-data,omitempty"`
+}
+
+// Kea returns reservations in Kea DHCPv4 JSON format ({"reservations": [...]}), which may be placed in a subnet4 entry.
+// Option 150 is sent as hex data, so it doesn't need a definition
+func Kea(hosts []Host, opts Options) ([]byte, error) {
+	err := opts.Check()
+	if err != nil {
+		return nil, err
+	}
+
+	reservations := []keaReservation{}
+	for _, h := range hosts {
+		if err := h.Check(); err != nil {
+			return nil, err
+		}
+
+		r := keaReservation{Hostname: h.Hostname, HWAddress: h.MAC, IPAddress: h.IP}
+
+		if opts.TFTPServer != "" {
+			binary := false
+			r.OptionData = append(r.OptionData,
+				keaOption{Name: "tftp-server-name", Data: opts.TFTPServer},
+				keaOption{Code: 150, CSVFormat: &binary, Data: fmt.Sprintf("%X", []byte(net.ParseIP(opts.TFTPServer).To4()))},
+			)
+		}
+		if h.BootFile != "" {
+			r.OptionData = append(r.OptionData, keaOption{Name: "boot-file-name", Data: h.BootFile})
+		}
+
+		reservations = append(reservations, r)
+	}
+
+	b, err := json.MarshalIndent(map[string]interface{}{"reservations": reservations}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
+
+// ISC returns host declarations of ISC dhcpd preceded by a definition of option 150 (cisco-tftp-server)
+func ISC(hosts []Host, opts Options) (string, error) {
+	err := opts.Check()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if opts.TFTPServer != "" {
+		b.WriteString("option cisco-tftp-server code 150 = array of ip-address;\n\n")
+	}
+
+	for _, h := range hosts {
+		if err := h.Check(); err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(&b, "host %s {\n", h.Hostname)
+		fmt.Fprintf(&b, "  hardware ethernet %s;\n", h.MAC)
+		fmt.Fprintf(&b, "  fixed-address %s;\n", h.IP)
+		if opts.TFTPServer != "" {
+			fmt.Fprintf(&b, "  option tftp-server-name \"%s\";\n", opts.TFTPServer)
+			fmt.Fprintf(&b, "  option cisco-tftp-server %s;\n", opts.TFTPServer)
+		}
+		if h.BootFile != "" {
+			fmt.Fprintf(&b, "  option bootfile-name \"%s\";\n", h.BootFile)
+		}
+		b.WriteString("}\n")
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,146 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"testing"
+)
+
+var hosts = []Host{
+	{Hostname: "r1", MAC: "00:50:56:aa:bb:cc", IP: "10.0.0.1", BootFile: "waw/r1.cfg"},
+	{Hostname: "r2", MAC: "00:50:56:aa:bb:cd", IP: "10.0.0.2"},
+}
+
+func TestKea(t *testing.T) {
+	b, err := Kea(hosts, Options{TFTPServer: "10.0.0.10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{
+  "reservations": [
+    {
+      "hostname": "r1",
+      "hw-address": "00:50:56:aa:bb:cc",
+      "ip-address": "10.0.0.1",
+      "option-data": [
+        {
+          "name": "tftp-server-name",
+          "data": "10.0.0.10"
+        },
+        {
+          "code": 150,
+          "csv-format": false,
+          "data": "0A00000A"
+        },
+        {
+          "name": "boot-file-name",
+          "data": "waw/r1.cfg"
+        }
+      ]
+    },
+    {
+      "hostname": "r2",
+      "hw-address": "00:50:56:aa:bb:cd",
+      "ip-address": "10.0.0.2",
+      "option-data": [
+        {
+          "name": "tftp-server-name",
+          "data": "10.0.0.10"
+        },
+        {
+          "code": 150,
+          "csv-format": false,
+          "data": "0A00000A"
+        }
+      ]
+    }
+  ]
+}
+`
+	if string(b) != expected {
+		t.Errorf("expected to get:\n%s\ninstead got:\n%s", expected, b)
+	}
+}
+
+func TestISC(t *testing.T) {
+	result, err := ISC(hosts[:1], Options{TFTPServer: "10.0.0.10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `option cisco-tftp-server code 150 = array of ip-address;
+
+host r1 {
+  hardware ethernet 00:50:56:aa:bb:cc;
+  fixed-address 10.0.0.1;
+  option tftp-server-name "10.0.0.10";
+  option cisco-tftp-server 10.0.0.10;
+  option bootfile-name "waw/r1.cfg";
+}
+`
+	if result != expected {
+		t.Errorf("expected to get:\n%s\ninstead got:\n%s", expected, result)
+	}
+
+	result, err = ISC(hosts[1:], Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "host r2 {\n  hardware ethernet 00:50:56:aa:bb:cd;\n  fixed-address 10.0.0.2;\n}\n"
+	if result != expected {
+		t.Errorf("expected to get:\n%s\ninstead got:\n%s", expected, result)
+	}
+
+	if _, err = ISC(hosts, Options{TFTPServer: "tftp.acme.com"}); err == nil {
+		t.Error("expected to get an error for TFTP server given by name, instead got nil")
+	}
+}
+
+func TestHostCheck(t *testing.T) {
+	var testCases = []struct {
+		host  Host
+		isErr bool
+	}{
+		{Host{Hostname: "r1", IP: "10.0.0.1"}, false},
+		{Host{Hostname: "core-1.waw.acme.com", IP: "10.0.0.1", BootFile: "http://10.0.0.10/waw/r1.cfg"}, false},
+		{Host{Hostname: "r1 { }\nhost r2", IP: "10.0.0.1"}, true},
+		{Host{Hostname: "-r1", IP: "10.0.0.1"}, true},
+		{Host{Hostname: "r1..waw", IP: "10.0.0.1"}, true},
+		{Host{Hostname: "", IP: "10.0.0.1"}, true},
+		{Host{Hostname: "r1", IP: "10.0.0.1; deny booting"}, true},
+		{Host{Hostname: "r1", IP: "2001:db8::1"}, true},
+		{Host{Hostname: "r1", IP: "r1.acme.com"}, true},
+		{Host{Hostname: "r1", IP: "10.0.0.1", BootFile: "r1\";\n"}, true},
+	}
+
+	for _, tc := range testCases {
+		err := tc.host.Check()
+		if tc.isErr && err == nil {
+			t.Errorf("expected to get an error for %+v, instead got nil", tc.host)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("expected to get no error for %+v, instead got %s", tc.host, err)
+		}
+	}
+
+	invalid := []Host{{Hostname: "r1", MAC: "00:50:56:aa:bb:cc", IP: "10.0.0.300"}}
+	if _, err := Kea(invalid, Options{}); err == nil {
+		t.Error("expected to get an error of Kea reservations for an invalid IP address, instead got nil")
+	}
+	if _, err := ISC(invalid, Options{}); err == nil {
+		t.Error("expected to get an error of ISC reservations for an invalid IP address, instead got nil")
+	}
+}
@@ -2,6 +2,11 @@ module github.com/pegaz/go-tmpl
 
 require (
 	github.com/dspinhirne/netaddr-go v0.0.0-20180510133009-a6cfb692cb10
+	github.com/pelletier/go-toml v1.2.0
 	github.com/spf13/cobra v0.0.3
 	github.com/spf13/viper v1.3.1
+	golang.org/x/crypto v0.0.0-20181203042331-505ab145d0a9
+	golang.org/x/sys v0.0.0-20181205085412-a5c9d58dba9a
+	golang.org/x/text v0.3.0
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -0,0 +1,273 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inventory renders inventories of devices for automation tools: Ansible (YAML and INI), Nornir
+// (SimpleInventory hosts and groups files) and NAPALM (JSON list of devices)
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Host is a single device of an inventory
+type Host struct {
+	Name string
+	// Address is a hostname or an IP address used to connect to a device, Name is used when it is empty
+	Address string
+	// Platform is a network OS of a device, e.g. ios, junos, eos (as named by NAPALM drivers)
+	Platform string
+	Groups   []string
+	Vars     map[string]interface{}
+	// ConfigFile is a path of a generated config of a device
+	ConfigFile string
+}
+
+// address returns an address used to connect to a device
+func (h Host) address() string {
+	if h.Address != "" {
+		return h.Address
+	}
+
+	return h.Name
+}
+
+// reGroupInvalid matches characters not allowed in names of Ansible groups
+var reGroupInvalid = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// GroupName returns a name of a group made of a value of a column, characters which aren't letters, digits or
+// underscores are replaced with underscores (e.g. 'core-dc1' becomes 'core_dc1')
+func GroupName(value string) string {
+	name := reGroupInvalid.ReplaceAllString(strings.TrimSpace(value), "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+
+	return name
+}
+
+// Groups returns names of all groups of hosts in order of their first appearance
+func Groups(hosts []Host) []string {
+	var groups []string
+	seen := make(map[string]bool)
+
+	for _, h := range hosts {
+		for _, g := range h.Groups {
+			if !seen[g] {
+				groups = append(groups, g)
+				seen[g] = true
+			}
+		}
+	}
+
+	return groups
+}
+
+// ReservedVars are names of variables set by inventories themselves, variables of hosts with these names are skipped,
+// so they don't duplicate keys of YAML inventories
+var ReservedVars = []string{"ansible_host", "ansible_network_os", "config_file"}
+
+// sortedVars returns variables of a host sorted by their names, except for reserved ones
+func sortedVars(vars map[string]interface{}, reserved []string) yaml.MapSlice {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		if !contains(reserved, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	sorted := yaml.MapSlice{}
+	for _, name := range names {
+		sorted = append(sorted, yaml.MapItem{Key: name, Value: vars[name]})
+	}
+
+	return sorted
+}
+
+// ansibleVars returns variables of a host used by Ansible
+func ansibleVars(h Host) yaml.MapSlice {
+	vars := yaml.MapSlice{{Key: "ansible_host", Value: h.address()}}
+	if h.Platform != "" {
+		vars = append(vars, yaml.MapItem{Key: "ansible_network_os", Value: h.Platform})
+	}
+	if h.ConfigFile != "" {
+		vars = append(vars, yaml.MapItem{Key: "config_file", Value: h.ConfigFile})
+	}
+
+	return append(vars, sortedVars(h.Vars, ReservedVars)...)
+}
+
+// AnsibleYAML returns Ansible inventory in YAML format, variables of hosts are placed in 'all' group
+// and other groups list their hosts only
+func AnsibleYAML(hosts []Host) ([]byte, error) {
+	all := yaml.MapSlice{}
+	for _, h := range hosts {
+		all = append(all, yaml.MapItem{Key: h.Name, Value: ansibleVars(h)})
+	}
+
+	children := yaml.MapSlice{}
+	for _, g := range Groups(hosts) {
+		members := yaml.MapSlice{}
+		for _, h := range hosts {
+			if contains(h.Groups, g) {
+				members = append(members, yaml.MapItem{Key: h.Name, Value: map[string]interface{}{}})
+			}
+		}
+		children = append(children, yaml.MapItem{Key: g, Value: yaml.MapSlice{{Key: "hosts", Value: members}}})
+	}
+
+	group := yaml.MapSlice{{Key: "hosts", Value: all}}
+	if len(children) > 0 {
+		group = append(group, yaml.MapItem{Key: "children", Value: children})
+	}
+
+	return yaml.Marshal(yaml.MapSlice{{Key: "all", Value: group}})
+}
+
+// AnsibleINI returns Ansible inventory in INI format, hosts with their variables are listed at the top
+// and followed by sections of groups
+func AnsibleINI(hosts []Host) (string, error) {
+	var b strings.Builder
+
+	for _, h := range hosts {
+		b.WriteString(h.Name)
+		for _, v := range ansibleVars(h) {
+			value, err := iniValue(v.Value)
+			if err != nil {
+				return "", fmt.Errorf("variable '%s' of host %s: %s", v.Key, h.Name, err)
+			}
+			fmt.Fprintf(&b, " %s=%s", v.Key, value)
+		}
+		b.WriteString("\n")
+	}
+
+	for _, g := range Groups(hosts) {
+		fmt.Fprintf(&b, "\n[%s]\n", g)
+		for _, h := range hosts {
+			if contains(h.Groups, g) {
+				b.WriteString(h.Name + "\n")
+			}
+		}
+	}
+
+	return b.String(), nil
+}
+
+// iniValue formats a value of a variable of INI inventory, strings with whitespaces or quotes are quoted
+// and lists are written as JSON arrays
+func iniValue(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case string:
+		if value == "" || strings.ContainsAny(value, " \t\"'=#;") {
+			b, err := json.Marshal(value)
+			return string(b), err
+		}
+		return value, nil
+	case []string:
+		b, err := json.Marshal(value)
+		return string(b), err
+	}
+
+	return fmt.Sprint(v), nil
+}
+
+// nornirData returns data of a Nornir host: its config file and variables
+func nornirData(h Host) yaml.MapSlice {
+	data := yaml.MapSlice{}
+	if h.ConfigFile != "" {
+		data = append(data, yaml.MapItem{Key: "config_file", Value: h.ConfigFile})
+	}
+
+	return append(data, sortedVars(h.Vars, []string{"config_file"})...)
+}
+
+// NornirHosts returns hosts file of Nornir SimpleInventory
+func NornirHosts(hosts []Host) ([]byte, error) {
+	inventory := yaml.MapSlice{}
+
+	for _, h := range hosts {
+		host := yaml.MapSlice{{Key: "hostname", Value: h.address()}}
+		if h.Platform != "" {
+			host = append(host, yaml.MapItem{Key: "platform", Value: h.Platform})
+		}
+		if len(h.Groups) > 0 {
+			host = append(host, yaml.MapItem{Key: "groups", Value: h.Groups})
+		}
+		if data := nornirData(h); len(data) > 0 {
+			host = append(host, yaml.MapItem{Key: "data", Value: data})
+		}
+
+		inventory = append(inventory, yaml.MapItem{Key: h.Name, Value: host})
+	}
+
+	return yaml.Marshal(inventory)
+}
+
+// NornirGroups returns groups file of Nornir SimpleInventory with all groups of hosts
+func NornirGroups(hosts []Host) ([]byte, error) {
+	groups := yaml.MapSlice{}
+	for _, g := range Groups(hosts) {
+		groups = append(groups, yaml.MapItem{Key: g, Value: map[string]interface{}{}})
+	}
+
+	return yaml.Marshal(groups)
+}
+
+// napalmDevice is a device of NAPALM inventory, hostname and driver are arguments of a NAPALM driver
+// and config_file may be loaded with load_replace_candidate
+type napalmDevice struct {
+	Name       string                 `json:"name"`
+	Hostname   string                 `json:"hostname"`
+	Driver     string                 `json:"driver,omitempty"`
+	ConfigFile string                 `json:"config_file,omitempty"`
+	Groups     []string               `json:"groups,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+// NAPALM returns a JSON list of devices with arguments of NAPALM drivers and paths of their configs
+func NAPALM(hosts []Host) ([]byte, error) {
+	devices := []napalmDevice{}
+	for _, h := range hosts {
+		devices = append(devices, napalmDevice{
+			Name:       h.Name,
+			Hostname:   h.address(),
+			Driver:     h.Platform,
+			ConfigFile: h.ConfigFile,
+			Groups:     h.Groups,
+			Data:       h.Vars,
+		})
+	}
+
+	b, err := json.MarshalIndent(devices, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
+
+func contains(arr []string, str string) bool {
+	for _, a := range arr {
+		if a == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+var hosts = []Host{
+	{
+		Name:       "r1",
+		Address:    "10.0.0.1",
+		Platform:   "ios",
+		Groups:     []string{"waw", "core"},
+		Vars:       map[string]interface{}{"site": "waw", "ports": 48, "vlans": []string{"10", "20"}},
+		ConfigFile: "/ws/output/waw/r1.cfg",
+	},
+	{
+		Name:   "r2",
+		Groups: []string{"krk"},
+		Vars:   map[string]interface{}{"descr": "access switch"},
+	},
+}
+
+func TestAnsibleYAML(t *testing.T) {
+	b, err := AnsibleYAML(hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `all:
+  hosts:
+    r1:
+      ansible_host: 10.0.0.1
+      ansible_network_os: ios
+      config_file: /ws/output/waw/r1.cfg
+      ports: 48
+      site: waw
+      vlans:
+      - "10"
+      - "20"
+    r2:
+      ansible_host: r2
+      descr: access switch
+  children:
+    waw:
+      hosts:
+        r1: {}
+    core:
+      hosts:
+        r1: {}
+    krk:
+      hosts:
+        r2: {}
+`
+	if string(b) != expected {
+		t.Errorf("expected to get:\n%s\ninstead got:\n%s", expected, b)
+	}
+}
+
+func TestAnsibleINI(t *testing.T) {
+	ini, err := AnsibleINI(hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `r1 ansible_host=10.0.0.1 ansible_network_os=ios config_file=/ws/output/waw/r1.cfg ports=48 site=waw vlans=["10","20"]
+r2 ansible_host=r2 descr="access switch"
+
+[waw]
+r1
+
+[core]
+r1
+
+[krk]
+r2
+`
+	if ini != expected {
+		t.Errorf("expected to get:\n%s\ninstead got:\n%s", expected, ini)
+	}
+}
+
+func TestNornir(t *testing.T) {
+	b, err := NornirHosts(hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `r1:
+  hostname: 10.0.0.1
+  platform: ios
+  groups:
+  - waw
+  - core
+  data:
+    config_file: /ws/output/waw/r1.cfg
+    ports: 48
+    site: waw
+    vlans:
+    - "10"
+    - "20"
+r2:
+  hostname: r2
+  groups:
+  - krk
+  data:
+    descr: access switch
+`
+	if string(b) != expected {
+		t.Errorf("expected to get:\n%s\ninstead got:\n%s", expected, b)
+	}
+
+	b, err = NornirGroups(hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `waw: {}
+core: {}
+krk: {}
+`
+	if string(b) != expected {
+		t.Errorf("expected to get:\n%s\ninstead got:\n%s", expected, b)
+	}
+}
+
+func TestNAPALM(t *testing.T) {
+	b, err := NAPALM(hosts[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[
+  {
+    "name": "r2",
+    "hostname": "r2",
+    "groups": [
+      "krk"
+    ],
+    "data": {
+      "descr": "access switch"
+    }
+  }
+]
+`
+	if string(b) != expected {
+		t.Errorf("expected to get:\n%s\ninstead got:\n%s", expected, b)
+	}
+}
+
+func TestGroupName(t *testing.T) {
+	var testCases = []struct {
+		value    string
+		expected string
+	}{
+		{"waw", "waw"},
+		{"core-dc1", "core_dc1"},
+		{" Warsaw 2 ", "Warsaw_2"},
+		{"1st-floor", "_1st_floor"},
+	}
+
+	for _, tc := range testCases {
+		if got := GroupName(tc.value); got != tc.expected {
+			t.Errorf("expected to get '%s' for '%s', instead got '%s'", tc.expected, tc.value, got)
+		}
+	}
+}
+
+func TestGroups(t *testing.T) {
+	expected := []string{"waw", "core", "krk"}
+	if got := Groups(hosts); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected to get %v, instead got %v", expected, got)
+	}
+}
+
+func TestReservedVars(t *testing.T) {
+	h := []Host{{
+		Name:       "r1",
+		Address:    "10.0.0.1",
+		Platform:   "ios",
+		Vars:       map[string]interface{}{"ansible_host": "r1.acme.com", "ansible_network_os": "eos", "config_file": "r1.txt", "site": "waw"},
+		ConfigFile: "/ws/output/r1.cfg",
+	}}
+
+	b, err := AnsibleYAML(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ansible map[string]map[string]map[string]map[string]string
+	if err = yaml.UnmarshalStrict(b, &ansible); err != nil {
+		t.Fatalf("expected to get valid YAML inventory, instead got: %s\n%s", err, b)
+	}
+	expected := map[string]string{"ansible_host": "10.0.0.1", "ansible_network_os": "ios", "config_file": "/ws/output/r1.cfg", "site": "waw"}
+	if vars := ansible["all"]["hosts"]["r1"]; !reflect.DeepEqual(vars, expected) {
+		t.Errorf("expected to get variables %v, instead got %v", expected, vars)
+	}
+
+	b, err = NornirHosts(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nornir map[string]map[string]interface{}
+	if err = yaml.UnmarshalStrict(b, &nornir); err != nil {
+		t.Fatalf("expected to get valid YAML hosts, instead got: %s\n%s", err, b)
+	}
+	data, _ := nornir["r1"]["data"].(map[interface{}]interface{})
+	if data["config_file"] != "/ws/output/r1.cfg" || data["ansible_host"] != "r1.acme.com" {
+		t.Errorf("expected to get config file of a host and other variables in data, instead got %v", data)
+	}
+}
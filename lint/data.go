@@ -0,0 +1,82 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// reYAMLErrorLine matches line number in errors returned by YAML parser
+var reYAMLErrorLine = regexp.MustCompile(`line (\d+):\s*`)
+
+// JSON checks if content is a well-formed JSON document
+func JSON(content string) []Problem {
+	var v interface{}
+
+	err := json.Unmarshal([]byte(content), &v)
+	if err == nil {
+		return nil
+	}
+
+	if e, ok := err.(*json.SyntaxError); ok {
+		return []Problem{{lineOf(content, e.Offset), e.Error()}}
+	}
+
+	return []Problem{{0, err.Error()}}
+}
+
+// lineOf returns number of a line containing byte at a given offset
+func lineOf(content string, offset int64) int {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+
+	return strings.Count(content[:offset], "\n") + 1
+}
+
+// YAML checks if content is a well-formed YAML document (or a stream of documents)
+func YAML(content string) []Problem {
+	var problems []Problem
+
+	decoder := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var v interface{}
+
+		err := decoder.Decode(&v)
+		if err == nil {
+			continue
+		}
+		if err == io.EOF {
+			break
+		}
+
+		msg := strings.TrimPrefix(err.Error(), "yaml: ")
+		line := 0
+		if m := reYAMLErrorLine.FindStringSubmatch(msg); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			msg = strings.Replace(msg, m[0], "", 1)
+		}
+		problems = append(problems, Problem{line, msg})
+		break
+	}
+
+	return problems
+}
@@ -0,0 +1,86 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// reBanner matches the first line of a banner, e.g. 'banner motd ^C' or 'banner login #'
+var reBanner = regexp.MustCompile(`^banner\s+\S+\s+(\^C|\S)`)
+
+// IOS checks indentation hierarchy of Cisco IOS and Arista EOS configuration: configuration starts at the first column,
+// lines are indented with spaces only and indentation may decrease only back to a level of one of enclosing sections.
+// Comments ('!') and banners are skipped
+func IOS(content string) []Problem {
+	var problems []Problem
+	// levels holds indentation of enclosing sections, the top one is indentation of a previous line
+	var levels []int
+	var banner string
+
+	for i, line := range lines(content) {
+		n := i + 1
+
+		if banner != "" {
+			if strings.Contains(line, banner) {
+				banner = ""
+			}
+			continue
+		}
+
+		trimmed := strings.TrimLeft(line, " \t")
+		if strings.TrimSpace(trimmed) == "" || strings.HasPrefix(trimmed, "!") {
+			continue
+		}
+
+		indentation := line[:len(line)-len(trimmed)]
+		if strings.Contains(indentation, "\t") {
+			problems = append(problems, Problem{n, "tab used for indentation"})
+			continue
+		}
+		indent := len(indentation)
+
+		if m := reBanner.FindStringSubmatch(trimmed); m != nil && indent == 0 {
+			// banner may end in its first line, e.g. 'banner motd ^C Authorized access only ^C'
+			if !strings.Contains(trimmed[len(m[0]):], m[1]) {
+				banner = m[1]
+			}
+		}
+
+		if levels == nil {
+			if indent > 0 {
+				problems = append(problems, Problem{n, "configuration should start at the first column"})
+			}
+			levels = []int{0}
+		}
+
+		if top := levels[len(levels)-1]; indent > top {
+			levels = append(levels, indent)
+			continue
+		}
+
+		for len(levels) > 1 && levels[len(levels)-1] > indent {
+			levels = levels[:len(levels)-1]
+		}
+		if levels[len(levels)-1] != indent {
+			problems = append(problems, Problem{n, fmt.Sprintf("indentation of %d spaces doesn't match any enclosing section", indent)})
+			levels = append(levels, indent)
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,142 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// reJunosSetCommand matches commands allowed in set-style Junos configuration
+var reJunosSetCommand = regexp.MustCompile(`^(set|delete|activate|deactivate|annotate|insert|rename|replace|protect|unprotect)\s`)
+
+// Junos checks Junos configuration. Configuration with 'set' commands has to consist of set-style commands only,
+// otherwise braces have to be balanced and every statement has to end with ';', '{' or '}'
+func Junos(content string) []Problem {
+	var setStyle bool
+	for _, line := range lines(content) {
+		line = strings.TrimSpace(line)
+		if line == "" || isJunosComment(line) {
+			continue
+		}
+		setStyle = reJunosSetCommand.MatchString(line + " ")
+		break
+	}
+
+	if setStyle {
+		return junosSet(content)
+	}
+
+	return junosBraces(content)
+}
+
+// isJunosComment reports whether line is a comment
+func isJunosComment(line string) bool {
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, "/*")
+}
+
+// junosSet checks if all lines are set-style commands
+func junosSet(content string) []Problem {
+	var problems []Problem
+
+	for i, line := range lines(content) {
+		line = strings.TrimSpace(line)
+		if line == "" || isJunosComment(line) {
+			continue
+		}
+
+		if !reJunosSetCommand.MatchString(line + " ") {
+			problems = append(problems, Problem{i + 1, fmt.Sprintf("'%s' is not a set-style command", line)})
+			continue
+		}
+		if strings.Count(line, `"`)%2 != 0 {
+			problems = append(problems, Problem{i + 1, "unclosed quote"})
+		}
+	}
+
+	return problems
+}
+
+// junosBraces checks balance of braces and terminators of statements in curly braces configuration
+func junosBraces(content string) []Problem {
+	var problems []Problem
+	// opened holds line numbers of braces which aren't closed yet
+	var opened []int
+	var inComment bool
+
+	for i, line := range lines(content) {
+		n := i + 1
+		var inQuote bool
+		var code strings.Builder
+
+		for j := 0; j < len(line); j++ {
+			ch := line[j]
+
+			switch {
+			case inComment:
+				if strings.HasPrefix(line[j:], "*/") {
+					inComment = false
+					j++
+				}
+				continue
+			case inQuote:
+				if ch == '\\' {
+					j++
+				} else if ch == '"' {
+					inQuote = false
+				}
+				code.WriteByte('x')
+				continue
+			case ch == '"':
+				inQuote = true
+			case ch == '#':
+				j = len(line)
+				continue
+			case strings.HasPrefix(line[j:], "/*"):
+				inComment = true
+				j++
+				continue
+			case ch == '{':
+				opened = append(opened, n)
+			case ch == '}':
+				if len(opened) == 0 {
+					problems = append(problems, Problem{n, "'}' without matching '{'"})
+				} else {
+					opened = opened[:len(opened)-1]
+				}
+			}
+			code.WriteByte(ch)
+		}
+
+		if inQuote {
+			problems = append(problems, Problem{n, "unclosed quote"})
+		}
+
+		statement := strings.TrimSpace(code.String())
+		if statement == "" {
+			continue
+		}
+		if last := statement[len(statement)-1]; last != ';' && last != '{' && last != '}' {
+			problems = append(problems, Problem{n, fmt.Sprintf("statement '%s' should end with ';', '{' or '}'", strings.TrimSpace(line))})
+		}
+	}
+
+	for _, n := range opened {
+		problems = append(problems, Problem{n, "'{' is never closed"})
+	}
+
+	return problems
+}
@@ -0,0 +1,90 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lint checks syntax of generated files, so broken configs are found before they reach devices
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Problem is a single syntax problem found in a file
+type Problem struct {
+	// Line number (starting from 1) or 0 when problem concerns the whole file
+	Line    int
+	Message string
+}
+
+// String returns problem prefixed with its line number
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+
+	return fmt.Sprintf("%d: %s", p.Line, p.Message)
+}
+
+// Linter checks content of a file and returns all problems found
+type Linter func(content string) []Problem
+
+// linters holds all registered linters by their names
+var linters = map[string]Linter{
+	"junos": Junos,
+	"ios":   IOS,
+	"eos":   IOS,
+	"json":  JSON,
+	"yaml":  YAML,
+}
+
+// Register registers a linter with a given name, registering under a name of existing linter replaces it
+func Register(name string, l Linter) {
+	linters[name] = l
+}
+
+// Names returns sorted names of all registered linters
+func Names() []string {
+	names := make([]string, 0, len(linters))
+	for name := range linters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Check checks if a linter with a given name is registered
+func Check(name string) error {
+	if _, ok := linters[name]; !ok {
+		return fmt.Errorf("unknown linter '%s', expected one of: %s", name, strings.Join(Names(), ", "))
+	}
+
+	return nil
+}
+
+// Lint checks content with a linter of a given name
+func Lint(name string, content string) ([]Problem, error) {
+	err := Check(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return linters[name](content), nil
+}
+
+// lines splits content into lines without line endings
+func lines(content string) []string {
+	return strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+}
@@ -0,0 +1,109 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	var testCases = []struct {
+		linter   string
+		content  string
+		expected []Problem
+	}{
+		{
+			linter:  "junos",
+			content: "## generated\nsystem {\n    host-name r1; # hostname\n    /* ntp */\n    ntp {\n        server 10.0.0.1;\n    }\n}\n",
+		},
+		{
+			linter:   "junos",
+			content:  "system {\n    host-name r1\n    ntp {\n        server 10.0.0.1;\n}\n",
+			expected: []Problem{{2, "statement 'host-name r1' should end with ';', '{' or '}'"}, {1, "'{' is never closed"}},
+		},
+		{
+			linter:   "junos",
+			content:  "system {\n    host-name \"r1 {\";\n}\n}\n",
+			expected: []Problem{{4, "'}' without matching '{'"}},
+		},
+		{
+			linter:  "junos",
+			content: "# generated\nset system host-name r1\ndelete interfaces ge-0/0/0\n",
+		},
+		{
+			linter:   "junos",
+			content:  "set system host-name r1\nsystem {\nset system domain-name \"acme.com\n",
+			expected: []Problem{{2, "'system {' is not a set-style command"}, {3, "unclosed quote"}},
+		},
+		{
+			linter:  "ios",
+			content: "!\nhostname r1\n!\ninterface Gi0/1\n description uplink\n ip address 10.0.0.1 255.255.255.0\n!\nrouter bgp 65000\n address-family ipv4\n  network 10.0.0.0\n exit-address-family\nbanner motd ^C\n   Authorized access only\n ^C\nend\n",
+		},
+		{
+			linter:   "ios",
+			content:  " hostname r1\ninterface Gi0/1\n  description uplink\n shutdown\n\tno ip address\n",
+			expected: []Problem{{1, "configuration should start at the first column"}, {4, "indentation of 1 spaces doesn't match any enclosing section"}, {5, "tab used for indentation"}},
+		},
+		{
+			linter:  "json",
+			content: "{\n  \"hostname\": \"r1\",\n  \"vlans\": [10, 20]\n}\n",
+		},
+		{
+			linter:   "json",
+			content:  "{\n  \"hostname\": \"r1\",\n  \"vlans\": [10, 20],\n}\n",
+			expected: []Problem{{4, "invalid character '}' looking for beginning of object key string"}},
+		},
+		{
+			linter:  "yaml",
+			content: "---\nhostname: r1\nvlans: [10, 20]\n---\nhostname: r2\n",
+		},
+		{
+			linter:   "yaml",
+			content:  "hostname: r1\nvlans:\n  - 10\n - 20\n",
+			expected: []Problem{{3, "did not find expected key"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		problems, err := Lint(tc.linter, tc.content)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if len(problems) != 0 || len(tc.expected) != 0 {
+			if !reflect.DeepEqual(problems, tc.expected) {
+				t.Errorf("expected to get %v for '%s', instead got %v", tc.expected, tc.content, problems)
+			}
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	if err := Check("nxos"); err == nil {
+		t.Error("expected to get an error for unknown linter, instead got nil")
+	}
+
+	Register("nxos", IOS)
+	defer delete(linters, "nxos")
+
+	problems, err := Lint("nxos", "hostname n1\n  feature bgp\n")
+	if err != nil {
+		t.Error(err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("expected to get no problems, instead got %v", problems)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stage writes files into a staging copy of a directory, which replaces the directory only when all files
+// are written, so a failed run doesn't leave half-written files behind
+package stage
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Stage is a staging copy of a target directory
+type Stage struct {
+	// Dir is the staging directory, it is placed next to the target one
+	Dir    string
+	target string
+}
+
+// New creates a staging copy of a target directory with its files a keep function returns true for (it is called with
+// paths relative to the target directory, with forward slashes). Files are hard linked when possible. A staging
+// directory left by a failed run is removed
+func New(target string, keep func(name string) bool) (*Stage, error) {
+	target = filepath.Clean(target)
+	s := &Stage{
+		Dir:    filepath.Join(filepath.Dir(target), "."+filepath.Base(target)+".tmp"),
+		target: target,
+	}
+
+	err := os.RemoveAll(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(s.Dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.Walk(target, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == target {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(target, path)
+		if err != nil {
+			return err
+		}
+		if !keep(filepath.ToSlash(rel)) {
+			return nil
+		}
+
+		return s.copy(path, filepath.Join(s.Dir, rel), info.Mode())
+	})
+	if err != nil {
+		s.Abort()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// copy hard links a file into a staging directory or copies it when linking isn't possible
+func (s *Stage) copy(src string, dst string, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+
+	if os.Link(src, dst) == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// path returns a path of a file in a staging directory and checks if it doesn't point outside of it
+func (s *Stage) path(name string) (string, error) {
+	name = filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(name) || name == "." || strings.HasPrefix(name, "..") {
+		return "", fmt.Errorf("path %s points outside of the staging directory", name)
+	}
+
+	return filepath.Join(s.Dir, name), nil
+}
+
+// WriteFile writes a file to a staging directory. A file linked from the target directory is removed first,
+// so the target one isn't changed
+func (s *Stage) WriteFile(name string, content []byte) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return ioutil.WriteFile(path, content, 0644)
+}
+
+// Remove removes a file from a staging directory together with directories left empty. It reports whether
+// the file existed
+func (s *Stage) Remove(name string) (bool, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return false, err
+	}
+
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for dir := filepath.Dir(path); dir != s.Dir; dir = filepath.Dir(dir) {
+		// removing a directory which isn't empty fails
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+
+	return true, nil
+}
+
+// Commit replaces the target directory with a staging one. Directories are swapped atomically where the system
+// supports it. Otherwise the target directory is renamed first and restored when the staging one can't be renamed,
+// so the target directory is missing for a moment
+func (s *Stage) Commit() error {
+	old := filepath.Join(filepath.Dir(s.target), "."+filepath.Base(s.target)+".old")
+
+	err := os.RemoveAll(old)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stat(s.target)
+	exists := err == nil
+
+	if exists && exchange(s.Dir, s.target) == nil {
+		// the staging directory holds the previous content now
+		return os.RemoveAll(s.Dir)
+	}
+
+	if exists {
+		err = os.Rename(s.target, old)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.Rename(s.Dir, s.target)
+	if err != nil {
+		if exists {
+			if rerr := os.Rename(old, s.target); rerr != nil {
+				return fmt.Errorf("%s, previous content is left in %s: %s", err, old, rerr)
+			}
+		}
+		return err
+	}
+
+	return os.RemoveAll(old)
+}
+
+// Abort removes a staging directory, the target one is left untouched. It does nothing after Commit
+func (s *Stage) Abort() error {
+	return os.RemoveAll(s.Dir)
+}
@@ -0,0 +1,143 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setup creates a target directory with given files in a temporary directory
+func setup(t *testing.T, files map[string]string) (string, func()) {
+	dir, err := ioutil.TempDir("", "stage")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(dir, "output")
+	for name, content := range files {
+		path := filepath.Join(target, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return target, func() { os.RemoveAll(dir) }
+}
+
+func read(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestCommit(t *testing.T) {
+	target, cleanup := setup(t, map[string]string{
+		"README.md":  "readme",
+		"waw/r1.cfg": "hostname r1",
+		"old/r3.cfg": "hostname r3",
+		"r2.txt":     "hostname r2",
+	})
+	defer cleanup()
+
+	s, err := New(target, func(name string) bool { return name != "r2.txt" })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.WriteFile("waw/r1.cfg", []byte("hostname r1-new")); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, filepath.Join(target, "waw", "r1.cfg")); got != "hostname r1" {
+		t.Errorf("expected target file to be unchanged before commit, instead got '%s'", got)
+	}
+
+	if removed, err := s.Remove("old/r3.cfg"); !removed || err != nil {
+		t.Errorf("expected to remove old/r3.cfg, instead got %t (error: %v)", removed, err)
+	}
+	if removed, err := s.Remove("r4.cfg"); removed || err != nil {
+		t.Errorf("expected to get false for missing file, instead got %t (error: %v)", removed, err)
+	}
+	if err = s.WriteFile("../r5.cfg", nil); err == nil {
+		t.Errorf("expected to get an error for path outside of staging directory, instead got nil")
+	}
+
+	if err = s.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := read(t, filepath.Join(target, "waw", "r1.cfg")); got != "hostname r1-new" {
+		t.Errorf("expected to get written file after commit, instead got '%s'", got)
+	}
+	if got := read(t, filepath.Join(target, "README.md")); got != "readme" {
+		t.Errorf("expected to keep README.md, instead got '%s'", got)
+	}
+
+	for _, name := range []string{"r2.txt", "old", "../.output.tmp", "../.output.old"} {
+		if _, err := os.Stat(filepath.Join(target, name)); !os.IsNotExist(err) {
+			t.Errorf("expected %s not to exist after commit, instead got: %v", name, err)
+		}
+	}
+}
+
+func TestAbort(t *testing.T) {
+	target, cleanup := setup(t, map[string]string{"r1.cfg": "hostname r1"})
+	defer cleanup()
+
+	s, err := New(target, func(string) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.WriteFile("r1.cfg", []byte("half")); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Abort(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := read(t, filepath.Join(target, "r1.cfg")); got != "hostname r1" {
+		t.Errorf("expected target file to be unchanged after abort, instead got '%s'", got)
+	}
+	if _, err := os.Stat(s.Dir); !os.IsNotExist(err) {
+		t.Errorf("expected staging directory to be removed, instead got: %v", err)
+	}
+}
+
+func TestNewTarget(t *testing.T) {
+	target, cleanup := setup(t, nil)
+	defer cleanup()
+
+	s, err := New(target, func(string) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.WriteFile("r1.cfg", []byte("hostname r1")); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := read(t, filepath.Join(target, "r1.cfg")); got != "hostname r1" {
+		t.Errorf("expected to create target directory, instead got '%s'", got)
+	}
+}
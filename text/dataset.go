@@ -0,0 +1,238 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"fmt"
+	"sync"
+	"text/template"
+)
+
+// Dataset gives templates read-only access to all rows read from CSV file. Rows are indexed
+// by a column on the first lookup, so subsequent lookups by the same column are fast
+type Dataset struct {
+	rows    []map[string]interface{}
+	columns map[string]bool
+	mu      sync.Mutex
+	index   map[string]map[string][]int
+}
+
+// noDataset is used by lookup functions of templates without a dataset, it behaves as an empty one
+var noDataset *Dataset
+
+// NewDataset creates and returns pointer to the Dataset with copies of given rows
+func NewDataset(rows []map[string]interface{}) *Dataset {
+	ds := &Dataset{
+		rows:    make([]map[string]interface{}, len(rows)),
+		columns: make(map[string]bool),
+		index:   make(map[string]map[string][]int),
+	}
+
+	for i, row := range rows {
+		ds.rows[i] = copyRow(row)
+		for column := range row {
+			ds.columns[column] = true
+		}
+	}
+
+	return ds
+}
+
+// copyRow returns a copy of a given row, so templates can't modify rows of a dataset
+func copyRow(row map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(row))
+	for k, v := range row {
+		c[k] = v
+	}
+
+	return c
+}
+
+// Len returns number of rows in the dataset
+func (ds *Dataset) Len() int {
+	if ds == nil {
+		return 0
+	}
+
+	return len(ds.rows)
+}
+
+// Rows returns copies of all rows in the dataset
+func (ds *Dataset) Rows() []map[string]interface{} {
+	rows := make([]map[string]interface{}, ds.Len())
+	for i := range rows {
+		rows[i] = copyRow(ds.rows[i])
+	}
+
+	return rows
+}
+
+// checkColumns returns an error if any of given columns is not a column of the dataset. An empty dataset
+// has no known columns, so nothing is reported for it
+func (ds *Dataset) checkColumns(columns ...string) error {
+	if ds.Len() == 0 {
+		return nil
+	}
+
+	for _, column := range columns {
+		if !ds.columns[column] {
+			return fmt.Errorf("no column '%s' in the data", column)
+		}
+	}
+
+	return nil
+}
+
+// columnIndex returns indexes of rows grouped by values of a column, the index is built on the first use of a column
+func (ds *Dataset) columnIndex(column string) map[string][]int {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	idx, ok := ds.index[column]
+	if !ok {
+		idx = make(map[string][]int)
+		for i, row := range ds.rows {
+			if v, ok := row[column]; ok {
+				idx[ToString(v)] = append(idx[ToString(v)], i)
+			}
+		}
+		ds.index[column] = idx
+	}
+
+	return idx
+}
+
+// find returns indexes of rows where column has a given value
+func (ds *Dataset) find(column string, value interface{}) []int {
+	if ds == nil {
+		return nil
+	}
+
+	return ds.columnIndex(column)[ToString(value)]
+}
+
+// Lookup returns value of retColumn from the first row where column has a given value or an empty string if there is no such row.
+// Values are compared as strings, so typed columns may be looked up with values of other columns
+func (ds *Dataset) Lookup(column string, value interface{}, retColumn string) interface{} {
+	v, ok := ds.LookupRow(column, value)[retColumn]
+	if !ok {
+		return ""
+	}
+
+	return v
+}
+
+// LookupRow returns the first row where column has a given value or an empty row if there is no such row
+func (ds *Dataset) LookupRow(column string, value interface{}) map[string]interface{} {
+	found := ds.find(column, value)
+	if len(found) == 0 {
+		return map[string]interface{}{}
+	}
+
+	return copyRow(ds.rows[found[0]])
+}
+
+// Where returns all rows where column has a given value
+func (ds *Dataset) Where(column string, value interface{}) []map[string]interface{} {
+	rows := []map[string]interface{}{}
+	for _, i := range ds.find(column, value) {
+		rows = append(rows, copyRow(ds.rows[i]))
+	}
+
+	return rows
+}
+
+// GroupBy returns all rows grouped by values of a given column
+func (ds *Dataset) GroupBy(column string) map[string][]map[string]interface{} {
+	groups := make(map[string][]map[string]interface{})
+	if ds == nil {
+		return groups
+	}
+
+	for value, found := range ds.columnIndex(column) {
+		rows := make([]map[string]interface{}, len(found))
+		for i, n := range found {
+			rows[i] = copyRow(ds.rows[n])
+		}
+		groups[value] = rows
+	}
+
+	return groups
+}
+
+// funcs returns lookup functions bound to the dataset, fill is applied to every returned row and used is called
+// whenever any of them is called. With strict set (missingkey=error) an unknown column is an error instead of an empty result
+func (ds *Dataset) funcs(strict bool, fill func(map[string]interface{}), used func()) template.FuncMap {
+	check := func(columns ...string) error {
+		used()
+		if !strict {
+			return nil
+		}
+		return ds.checkColumns(columns...)
+	}
+
+	return template.FuncMap{
+		"lookup": func(column string, value interface{}, retColumn string) (interface{}, error) {
+			if err := check(column, retColumn); err != nil {
+				return nil, err
+			}
+			return ds.Lookup(column, value, retColumn), nil
+		},
+		"lookup_row": func(column string, value interface{}) (map[string]interface{}, error) {
+			if err := check(column); err != nil {
+				return nil, err
+			}
+			row := ds.LookupRow(column, value)
+			// a row which wasn't found stays empty, so it's still false in 'if' and 'with'
+			if len(row) > 0 {
+				fill(row)
+			}
+			return row, nil
+		},
+		"where": func(column string, value interface{}) ([]map[string]interface{}, error) {
+			if err := check(column); err != nil {
+				return nil, err
+			}
+			rows := ds.Where(column, value)
+			for _, row := range rows {
+				fill(row)
+			}
+			return rows, nil
+		},
+		"group_by": func(column string) (map[string][]map[string]interface{}, error) {
+			if err := check(column); err != nil {
+				return nil, err
+			}
+			groups := ds.GroupBy(column)
+			for _, rows := range groups {
+				for _, row := range rows {
+					fill(row)
+				}
+			}
+			return groups, nil
+		},
+	}
+}
+
+// SetDataset sets the dataset available to lookup functions of a template
+func (t *Template) SetDataset(ds *Dataset) {
+	t.dataset = ds
+}
+
+// UsedDataset reports whether the last execution of a template looked up any rows of its dataset, so its output
+// depends on other rows than its own
+func (t *Template) UsedDataset() bool {
+	return t.usedDataset
+}
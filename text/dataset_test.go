@@ -0,0 +1,156 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDatasetLookup(t *testing.T) {
+	ds := NewDataset(exampleRows)
+
+	var testCases = []struct {
+		column    string
+		value     string
+		retColumn string
+		expected  string
+	}{
+		{"hostname", "r1", "loopback", "10.255.0.1"},
+		{"hostname", "r3", "site", "krk"},
+		{"site", "waw", "hostname", "r1"},
+		{"hostname", "r4", "loopback", ""},
+		{"hostname", "r1", "unknown", ""},
+		{"unknown", "r1", "loopback", ""},
+	}
+
+	for _, tc := range testCases {
+		result := ds.Lookup(tc.column, tc.value, tc.retColumn)
+		if result != tc.expected {
+			t.Errorf("expected to get '%s', instead got '%s'", tc.expected, result)
+		}
+	}
+}
+
+func TestDatasetReadOnly(t *testing.T) {
+	rows := []map[string]interface{}{{"hostname": "r1"}}
+	ds := NewDataset(rows)
+
+	rows[0]["hostname"] = "changed"
+	ds.LookupRow("hostname", "r1")["hostname"] = "changed"
+	ds.Where("hostname", "r1")[0]["hostname"] = "changed"
+	ds.GroupBy("hostname")["r1"][0]["hostname"] = "changed"
+
+	if result := ds.Lookup("hostname", "r1", "hostname"); result != "r1" {
+		t.Errorf("expected to get 'r1', instead got '%s'", result)
+	}
+}
+
+func TestDatasetNil(t *testing.T) {
+	var ds *Dataset
+
+	if ds.Len() != 0 || ds.Lookup("a", "b", "c") != "" || len(ds.Where("a", "b")) != 0 || len(ds.GroupBy("a")) != 0 {
+		t.Errorf("expected nil dataset to behave as an empty one")
+	}
+}
+
+func TestExecuteDataset(t *testing.T) {
+	content := `{{range where "site" .site}}{{if ne .hostname $.hostname}}neighbor {{.loopback}} via {{lookup "hostname" .hostname "site"}}
+{{end}}{{end}}`
+
+	tpl, err := NewTemplate(map[string]string{"hostname": "r1", "site": "waw"}, "bgp", strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl.SetDataset(NewDataset(exampleRows))
+
+	w := &strings.Builder{}
+	if err := tpl.Execute(w); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "neighbor 10.255.0.2 via waw\n"
+	if w.String() != expected {
+		t.Errorf("expected to get '%s', instead got '%s'", expected, w.String())
+	}
+	if !tpl.UsedDataset() {
+		t.Errorf("expected template with lookups to use dataset")
+	}
+
+	tpl, err = NewTemplate(map[string]string{"hostname": "r1"}, "plain", strings.NewReader("hostname {{.hostname}}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl.SetDataset(NewDataset(exampleRows))
+	if err := tpl.Execute(&strings.Builder{}); err != nil {
+		t.Fatal(err)
+	}
+	if tpl.UsedDataset() {
+		t.Errorf("expected template without lookups not to use dataset")
+	}
+}
+
+func TestDatasetGroupBy(t *testing.T) {
+	ds := NewDataset(exampleRows)
+
+	for i := 0; i < 2; i++ {
+		groups := ds.GroupBy("site")
+		if len(groups["waw"]) != 2 || groups["waw"][0]["hostname"] != "r1" || groups["waw"][1]["hostname"] != "r2" {
+			t.Errorf("expected to get rows r1 and r2 in group 'waw', instead got %v", groups["waw"])
+		}
+		if len(groups["krk"]) != 1 || groups["krk"][0]["hostname"] != "r3" {
+			t.Errorf("expected to get row r3 in group 'krk', instead got %v", groups["krk"])
+		}
+	}
+
+	if len(ds.index) != 1 {
+		t.Errorf("expected to get rows indexed once by column 'site', instead got %d indexes", len(ds.index))
+	}
+}
+
+func TestExecuteDatasetMissingKey(t *testing.T) {
+	var testCases = []struct {
+		content string
+		missing string
+		isErr   bool
+	}{
+		{`{{lookup "hostname" "r2" "loopback"}}`, "error", false},
+		{`{{lookup "hostname" "r9" "loopback"}}`, "error", false},
+		{`{{lookup "hostname" "r2" "unknown"}}`, "error", true},
+		{`{{lookup "unknown" "r2" "loopback"}}`, "error", true},
+		{`{{lookup_row "unknown" "r2"}}`, "error", true},
+		{`{{where "unknown" "r2"}}`, "error", true},
+		{`{{group_by "unknown"}}`, "error", true},
+		{`{{lookup "hostname" "r2" "unknown"}}`, "zero", false},
+		{`{{lookup "hostname" "r2" "unknown"}}`, "invalid", false},
+	}
+
+	for _, tc := range testCases {
+		tpl, err := NewTemplate(map[string]string{"hostname": "r1"}, "lookup", strings.NewReader(tc.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tpl.SetStrict(tc.missing)
+		tpl.SetDataset(NewDataset(exampleRows))
+
+		err = tpl.Execute(&strings.Builder{})
+		if tc.isErr && err == nil {
+			t.Errorf("expected to get an error for '%s' with missingkey=%s, instead got nil", tc.content, tc.missing)
+		}
+		if !tc.isErr && err != nil {
+			t.Errorf("expected to get no error for '%s' with missingkey=%s, instead got %s", tc.content, tc.missing, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultLeftDelim  = "{{"
+	DefaultRightDelim = "}}"
+)
+
+// builtinWords are functions and keywords of a template engine which may begin an action
+var builtinWords = map[string]bool{
+	"and": true, "or": true, "not": true, "len": true, "index": true, "slice": true, "call": true,
+	"print": true, "printf": true, "println": true, "html": true, "js": true, "urlquery": true,
+	"eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true,
+	"if": true, "else": true, "end": true, "range": true, "with": true, "define": true, "template": true,
+	"block": true, "break": true, "continue": true, "nil": true, "true": true, "false": true,
+}
+
+// foreignDelims are delimiters of other template engines (Jinja, Ansible) which are not expected in go templates.
+// Jinja's comments '{#' and '#}' are left out, as they are common in shell scripts (e.g. '${#var}')
+var foreignDelims = []string{"{%", "%}"}
+
+// SetDelims sets delimiters of template's actions used unless they are defined in template's front-matter
+func (t *Template) SetDelims(left, right string) {
+	t.delims = []string{left, right}
+}
+
+// templateDelims returns delimiters used by a template (front-matter first, then these set for a template, then the default ones)
+func (t *Template) templateDelims(fallback []string) (string, string) {
+	if len(t.FrontMatter.Delims) == 2 {
+		return t.FrontMatter.Delims[0], t.FrontMatter.Delims[1]
+	}
+	if len(fallback) == 2 && fallback[0] != "" && fallback[1] != "" {
+		return fallback[0], fallback[1]
+	}
+
+	return DefaultLeftDelim, DefaultRightDelim
+}
+
+// Warnings returns a list of places in template's content which look like unescaped delimiters of other template engines
+func (t *Template) Warnings() []string {
+	left, right := t.templateDelims(t.delims)
+
+	return CheckDelims(t.TemplateContent, left, right)
+}
+
+// CheckDelims looks for actions in content which don't look like go template's actions, e.g. '{{ ansible_host }}'
+// or Jinja's '{% if %}' statements, and returns a list of warnings (one per suspicious line)
+func CheckDelims(content, left, right string) []string {
+	var warnings []string
+
+	for n, line := range strings.Split(content, "\n") {
+		if left == DefaultLeftDelim {
+			for _, delim := range foreignDelims {
+				if strings.Contains(line, delim) {
+					warnings = append(warnings, fmt.Sprintf("line %d: '%s' looks like a delimiter of another template engine", n+1, delim))
+					break
+				}
+			}
+		}
+
+		for rest := line; ; {
+			i := strings.Index(rest, left)
+			if i < 0 {
+				break
+			}
+			rest = rest[i+len(left):]
+
+			action := rest
+			if j := strings.Index(rest, right); j >= 0 {
+				action = rest[:j]
+			}
+
+			if word := firstWord(action); word != "" && !builtinWords[word] && templateFuncs[word] == nil {
+				warnings = append(warnings, fmt.Sprintf("line %d: '%s%s' doesn't look like a template action, use other delimiters or escape it with %s\"%s\"%s", n+1, left, action, left, left, right))
+				break
+			}
+		}
+	}
+
+	return warnings
+}
+
+// firstWord returns an identifier which begins an action or an empty string if action begins with something else
+// (field, variable, literal, comment or a pipeline in parentheses)
+func firstWord(action string) string {
+	action = strings.TrimLeft(strings.TrimPrefix(action, "-"), " \t")
+
+	end := strings.IndexFunc(action, func(r rune) bool {
+		return !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+	})
+	if end < 0 {
+		end = len(action)
+	}
+
+	word := action[:end]
+	if word == "" || unicode.IsDigit(rune(word[0])) {
+		return ""
+	}
+
+	return word
+}
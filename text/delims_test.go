@@ -0,0 +1,62 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckDelims(t *testing.T) {
+	var testCases = []struct {
+		content  string
+		left     string
+		right    string
+		warnings int
+	}{
+		{"hostname {{.hostname}}\n{{if .vlan}}{{split .vlan \",\" 0}}{{end}}\n", "{{", "}}", 0},
+		{"{{- /* comment */ -}}\n{{ \"{{\" }} {{$x := 1}}{{(ip4 .ip 1)}}\n", "{{", "}}", 0},
+		{"ansible_host: {{ ansible_host }}\n", "{{", "}}", 1},
+		{"{% if x %}\n{%- endif %}\n", "{{", "}}", 2},
+		{"echo ${#hosts[@]} {#\n", "{{", "}}", 0},
+		{"ansible_host: {{ ansible_host }}\n[[.hostname]]\n", "[[", "]]", 0},
+		{"[[aggregate]]\n[[.hostname]]\n", "[[", "]]", 1},
+	}
+
+	for _, tc := range testCases {
+		warnings := CheckDelims(tc.content, tc.left, tc.right)
+		if len(warnings) != tc.warnings {
+			t.Errorf("expected to get %d warnings for '%s', instead got %d: %v", tc.warnings, tc.content, len(warnings), warnings)
+		}
+	}
+}
+
+func TestSetDelims(t *testing.T) {
+	tpl, err := NewTemplate(tplData, "test_template", strings.NewReader("<<.Name>> [[.Name]]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl.SetDelims("<<", ">>")
+
+	w := &strings.Builder{}
+	err = tpl.Execute(w)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if w.String() != "*name* [[.Name]]" {
+		t.Errorf("expected to get template filled with data, instead got '%s'", w.String())
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// Encoding describes how the output of a template is written to a file
+type Encoding struct {
+	// LineEndings of the output: lf, crlf or keep
+	LineEndings string
+	// FinalNewline enforces a new line at the end of the output
+	FinalNewline bool
+	// Charset of the output (e.g. utf-8, iso-8859-2, windows-1250)
+	Charset string
+}
+
+// CheckEncoding checks if line endings style and charset are known
+func CheckEncoding(e Encoding) error {
+	switch e.LineEndings {
+	case "", "lf", "crlf", "keep":
+	default:
+		return fmt.Errorf("unknown line endings: %s", e.LineEndings)
+	}
+
+	_, err := charset(e.Charset)
+
+	return err
+}
+
+// charset returns an encoding of a given name or nil for utf-8
+func charset(name string) (encoding.Encoding, error) {
+	switch strings.ToLower(name) {
+	case "", "utf-8", "utf8":
+		return nil, nil
+	}
+
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown charset: %s", name)
+	}
+
+	return enc, nil
+}
+
+// SetEncoding sets encoding of the output used unless it is defined in template's front-matter
+func (t *Template) SetEncoding(e Encoding) {
+	t.encoding = e
+}
+
+// Encoding returns encoding of the output used by a template (front-matter first, then the one set for a template)
+func (t *Template) Encoding() Encoding {
+	e := t.encoding
+
+	if t.FrontMatter.LineEndings != "" {
+		e.LineEndings = t.FrontMatter.LineEndings
+	}
+	if t.FrontMatter.FinalNewline != nil {
+		e.FinalNewline = *t.FrontMatter.FinalNewline
+	}
+	if t.FrontMatter.Charset != "" {
+		e.Charset = t.FrontMatter.Charset
+	}
+
+	return e
+}
+
+// Encode converts line endings of s, enforces final new line (if needed) and encodes it with a given charset
+func (e Encoding) Encode(s string) ([]byte, error) {
+	if e.FinalNewline && !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+
+	s = ConvertLineEndings(s, e.LineEndings)
+
+	enc, err := charset(e.Charset)
+	if err != nil {
+		return nil, err
+	}
+	if enc == nil {
+		return []byte(s), nil
+	}
+
+	b, err := enc.NewEncoder().String(s)
+	if err != nil {
+		return nil, fmt.Errorf("output can't be encoded with %s charset: %s", e.Charset, err)
+	}
+
+	return []byte(b), nil
+}
@@ -0,0 +1,80 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	var testCases = []struct {
+		input    string
+		encoding Encoding
+		expected []byte
+	}{
+		{"a\nb", Encoding{}, []byte("a\nb")},
+		{"a\nb", Encoding{LineEndings: "crlf", FinalNewline: true}, []byte("a\r\nb\r\n")},
+		{"a\r\nb\n", Encoding{LineEndings: "lf", FinalNewline: true}, []byte("a\nb\n")},
+		{"zażółć", Encoding{Charset: "iso-8859-2"}, []byte{'z', 'a', 0xbf, 0xf3, 0xb3, 0xe6}},
+		{"zażółć", Encoding{Charset: "UTF-8"}, []byte("zażółć")},
+	}
+
+	for _, tc := range testCases {
+		result, err := tc.encoding.Encode(tc.input)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !bytes.Equal(result, tc.expected) {
+			t.Errorf("expected to get %q, instead got %q", tc.expected, result)
+		}
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	var testCases = []struct {
+		input    string
+		encoding Encoding
+	}{
+		{"♠", Encoding{Charset: "iso-8859-2"}},
+		{"a", Encoding{Charset: "klingon"}},
+	}
+
+	for _, tc := range testCases {
+		_, err := tc.encoding.Encode(tc.input)
+		if err == nil {
+			t.Errorf("expected to get an error encoding %q with %+v, instead got nil", tc.input, tc.encoding)
+		}
+	}
+
+	if CheckEncoding(Encoding{LineEndings: "cr"}) == nil {
+		t.Error("expected to get an error for unknown line endings, instead got nil")
+	}
+}
+
+func TestTemplateEncoding(t *testing.T) {
+	tpl, err := NewTemplate(tplData, "test_template", strings.NewReader("{{/*---\nline_endings: crlf\nfinal_newline: false\n---*/}}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl.SetEncoding(Encoding{LineEndings: "lf", FinalNewline: true, Charset: "windows-1250"})
+
+	expected := Encoding{LineEndings: "crlf", FinalNewline: false, Charset: "windows-1250"}
+	if tpl.Encoding() != expected {
+		t.Errorf("expected to get encoding %+v, instead got %+v", expected, tpl.Encoding())
+	}
+}
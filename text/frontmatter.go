@@ -0,0 +1,123 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v2"
+)
+
+// reYAMLFrontMatter matches front-matter block delimited with '{{/*---' and '---*/}}' lines at the very beginning
+// of a template. Front-matter is a template comment, so outputs starting with '---' (e.g. YAML documents) aren't
+// mistaken for it
+var reYAMLFrontMatter = regexp.MustCompile(`^\{\{/\*---[ \t]*\r?\n((?s).*?)\r?\n?---\*/\}\}[ \t]*(\r?\n|$)`)
+
+// reTOMLFrontMatter matches front-matter block delimited with '{{/*+++' and '+++*/}}' lines at the very beginning
+// of a template
+var reTOMLFrontMatter = regexp.MustCompile(`^\{\{/\*\+\+\+[ \t]*\r?\n((?s).*?)\r?\n?\+\+\+\*/\}\}[ \t]*(\r?\n|$)`)
+
+// FrontMatter stores per-template settings read from YAML ('{{/*---') or TOML ('{{/*+++') block placed at the top
+// of a template
+type FrontMatter struct {
+	// Extends is a name of a base template
+	Extends string `yaml:"extends" toml:"extends"`
+	// Description is a human readable description of a template
+	Description string `yaml:"description" toml:"description"`
+	// MissingKey overrides workspace's 'missing_key' setting (zero, error or invalid)
+	MissingKey string `yaml:"missing_key" toml:"missing_key"`
+	// Extension of output files generated from a template (e.g. '.cfg')
+	Extension string `yaml:"extension" toml:"extension"`
+	// Path is a pattern (template itself) of an output filename relative to output directory
+	Path string `yaml:"path" toml:"path"`
+	// Required lists columns which have to be present and not empty in data row
+	Required []string `yaml:"required" toml:"required"`
+	// Delims are left and right delimiters of template's actions
+	Delims []string `yaml:"delims" toml:"delims"`
+	// LineEndings of the output (lf, crlf or keep)
+	LineEndings string `yaml:"line_endings" toml:"line_endings"`
+	// FinalNewline enforces a new line at the end of the output
+	FinalNewline *bool `yaml:"final_newline" toml:"final_newline"`
+	// Charset of the output (e.g. utf-8, iso-8859-2, windows-1250)
+	Charset string `yaml:"charset" toml:"charset"`
+	// PostProcess lists post-processing steps applied to the output, it overrides workspace's 'postprocess' setting
+	PostProcess []string `yaml:"postprocess" toml:"postprocess"`
+	// Lint is a name of a linter checking syntax of the output (e.g. junos, ios, json), 'none' disables linting
+	Lint string `yaml:"lint" toml:"lint"`
+}
+
+// parseFrontMatter reads front-matter from the beginning of a template and returns it together with the template's body stripped of it
+func parseFrontMatter(content string) (FrontMatter, string, error) {
+	var fm FrontMatter
+	var err error
+
+	if m := reYAMLFrontMatter.FindStringSubmatch(content); m != nil {
+		err = yaml.UnmarshalStrict([]byte(m[1]), &fm)
+		content = content[len(m[0]):]
+	} else if m := reTOMLFrontMatter.FindStringSubmatch(content); m != nil {
+		err = toml.Unmarshal([]byte(m[1]), &fm)
+		content = content[len(m[0]):]
+	}
+	if err != nil {
+		return fm, "", fmt.Errorf("invalid front-matter: %s", err)
+	}
+
+	return fm, content, fm.validate()
+}
+
+// validate checks if values set in front-matter are allowed
+func (fm FrontMatter) validate() error {
+	switch fm.MissingKey {
+	case "", "zero", "error", "invalid":
+	default:
+		return fmt.Errorf("invalid value for 'missing_key' in front-matter, got: %s", fm.MissingKey)
+	}
+
+	if err := CheckEncoding(Encoding{LineEndings: fm.LineEndings, Charset: fm.Charset}); err != nil {
+		return fmt.Errorf("invalid encoding of the output in front-matter: %s", err)
+	}
+
+	if err := CheckPostProcess(fm.PostProcess); err != nil {
+		return fmt.Errorf("invalid value for 'postprocess' in front-matter: %s", err)
+	}
+
+	if len(fm.Delims) != 0 && (len(fm.Delims) != 2 || fm.Delims[0] == "" || fm.Delims[1] == "") {
+		return fmt.Errorf("'delims' in front-matter should consist of exactly two non empty values")
+	}
+
+	return nil
+}
+
+// ExpandPath executes 'path' pattern of template's front-matter with its data and delimiters. A missing key is
+// an error regardless of 'missing_key' setting, so no file is named after '<no value>' or an empty value
+func (t *Template) ExpandPath() (string, error) {
+	tt, err := template.New(t.TemplateName).Option("missingkey=error").Funcs(templateFuncs).
+		Delims(t.templateDelims(t.delims)).Parse(t.FrontMatter.Path)
+	if err != nil {
+		return "", err
+	}
+
+	var path strings.Builder
+	err = tt.Execute(&path, t.context(nil))
+	if err != nil {
+		return "", err
+	}
+
+	return path.String(), nil
+}
@@ -0,0 +1,181 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	var testCases = []struct {
+		content  string
+		expected FrontMatter
+		body     string
+	}{
+		{
+			content: "{{/*---\ndescription: access switch\nmissing_key: error\nextension: cfg\npath: \"{{.site}}/{{.hostname}}\"\nrequired: [hostname, site]\ndelims: [\"[[\", \"]]\"]\nline_endings: crlf\n---*/}}\nhostname [[.hostname]]\n",
+			expected: FrontMatter{
+				Description: "access switch",
+				MissingKey:  "error",
+				Extension:   "cfg",
+				Path:        "{{.site}}/{{.hostname}}",
+				Required:    []string{"hostname", "site"},
+				Delims:      []string{"[[", "]]"},
+				LineEndings: "crlf",
+			},
+			body: "hostname [[.hostname]]\n",
+		},
+		{
+			content:  "{{/*+++\nextends = \"base\"\nmissing_key = \"zero\"\n+++*/}}\nbody",
+			expected: FrontMatter{Extends: "base", MissingKey: "zero"},
+			body:     "body",
+		},
+		{
+			content:  "no front-matter\n---\n",
+			expected: FrontMatter{},
+			body:     "no front-matter\n---\n",
+		},
+		{
+			content:  "---\nhostname: {{.hostname}}\n---\nhostname: r2\n",
+			expected: FrontMatter{},
+			body:     "---\nhostname: {{.hostname}}\n---\nhostname: r2\n",
+		},
+		{
+			content:  "+++\ntitle = \"{{.hostname}}\"\n+++\n",
+			expected: FrontMatter{},
+			body:     "+++\ntitle = \"{{.hostname}}\"\n+++\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		fm, body, err := parseFrontMatter(tc.content)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !reflect.DeepEqual(fm, tc.expected) {
+			t.Errorf("expected to get front-matter %+v, instead got %+v", tc.expected, fm)
+		}
+
+		if body != tc.body {
+			t.Errorf("expected to get body '%s', instead got '%s'", tc.body, body)
+		}
+	}
+}
+
+func TestParseFrontMatterErrors(t *testing.T) {
+	var testCases = []string{
+		"{{/*---\nmissing_key: sometimes\n---*/}}\n",
+		"{{/*---\nline_endings: cr\n---*/}}\n",
+		"{{/*---\ndelims: [\"[[\"]\n---*/}}\n",
+		"{{/*---\nunknown: setting\n---*/}}\n",
+		"{{/*+++\nextends = \n+++*/}}\n",
+	}
+
+	for _, tc := range testCases {
+		_, _, err := parseFrontMatter(tc)
+		if err == nil {
+			t.Errorf("expected to get an error for front-matter '%s', instead got nil", tc)
+		}
+	}
+}
+
+func TestExecuteFrontMatter(t *testing.T) {
+	var testCases = []struct {
+		content  string
+		data     map[string]string
+		expected string
+		isErr    bool
+	}{
+		{
+			content:  "{{/*---\ndelims: [\"[[\", \"]]\"]\n---*/}}\n{{ jinja }} [[.Name]]\n",
+			data:     map[string]string{"Name": "*name*"},
+			expected: "{{ jinja }} *name*\n",
+		},
+		{
+			content:  "{{/*---\nmissing_key: zero\n---*/}}\n[{{.Missing}}]",
+			data:     map[string]string{},
+			expected: "[]",
+		},
+		{
+			content: "{{/*---\nmissing_key: error\n---*/}}\n[{{.Missing}}]",
+			data:    map[string]string{},
+			isErr:   true,
+		},
+		{
+			content: "{{/*---\nrequired: [Name]\n---*/}}\n{{.Name}}",
+			data:    map[string]string{"Name": ""},
+			isErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tpl, err := NewTemplate(tc.data, "test_template", strings.NewReader(tc.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := &strings.Builder{}
+		err = tpl.Execute(w)
+		if tc.isErr {
+			if err == nil {
+				t.Errorf("expected to get an error for template '%s', instead got nil", tc.content)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+		}
+
+		if w.String() != tc.expected {
+			t.Errorf("expected to get '%s', instead got '%s'", tc.expected, w.String())
+		}
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	var testCases = []struct {
+		content  string
+		missing  string
+		expected string
+		isErr    bool
+	}{
+		{content: "{{/*---\npath: \"{{.site}}/{{.hostname}}\"\n---*/}}\n", expected: "waw/r1"},
+		{content: "{{/*---\npath: \"[[.site]]/[[.hostname | upper]]\"\ndelims: [\"[[\", \"]]\"]\n---*/}}\n", expected: "waw/R1"},
+		{content: "{{/*---\npath: \"{{.region}}/{{.hostname}}\"\n---*/}}\n", missing: "zero", isErr: true},
+		{content: "{{/*---\npath: \"{{fail \\\"no site\\\"}}\"\n---*/}}\n", isErr: true},
+	}
+
+	for _, tc := range testCases {
+		tpl, err := NewTemplate(map[string]string{"site": "waw", "hostname": "r1"}, "test_template", strings.NewReader(tc.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tpl.SetStrict(tc.missing)
+
+		path, err := tpl.ExpandPath()
+		if tc.isErr {
+			if err == nil {
+				t.Errorf("expected to get an error for path of '%s', instead got '%s'", tc.content, path)
+			}
+			continue
+		}
+		if err != nil || path != tc.expected {
+			t.Errorf("expected to get path '%s', instead got '%s' (error: %v)", tc.expected, path, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"reflect"
+	"sort"
+)
+
+// FuncDoc describes a function available in templates
+type FuncDoc struct {
+	Name      string
+	Signature string
+	Example   string
+	// Result is an output of the Example
+	Result string
+}
+
+// funcDocs holds signatures and examples of all functions registered in templateFuncs
+var funcDocs = map[string]FuncDoc{
+	"split":            {Signature: "split STR SEP IDX", Example: `{{split "10.0.0.0/24" "/" 1}}`, Result: "24"},
+	"ip4":              {Signature: "ip4 PREFIX IDX", Example: `{{ip4 "10.0.0.0/24" 1}}`, Result: "10.0.0.1"},
+	"ip4mask":          {Signature: "ip4mask PREFIX", Example: `{{ip4mask "10.0.0.0/24"}}`, Result: "255.255.255.0"},
+	"ip4cidr":          {Signature: "ip4cidr PREFIX", Example: `{{ip4cidr "10.0.0.0/24"}}`, Result: "24"},
+	"ip4mask_to_cidr":  {Signature: "ip4mask_to_cidr MASK", Example: `{{ip4mask_to_cidr "255.255.255.0"}}`, Result: "24"},
+	"ip4cidr_to_mask":  {Signature: "ip4cidr_to_mask CIDR", Example: `{{ip4cidr_to_mask "24"}}`, Result: "255.255.255.0"},
+	"ip4wildcard":      {Signature: "ip4wildcard PREFIX", Example: `{{ip4wildcard "10.0.0.0/22"}}`, Result: "0.0.3.255"},
+	"ip4_contains":     {Signature: "ip4_contains PREFIX IP", Example: `{{ip4_contains "10.0.0.0/8" "10.1.0.0/16"}}`, Result: "true"},
+	"ip4_overlaps":     {Signature: "ip4_overlaps PREFIX PREFIX", Example: `{{ip4_overlaps "10.0.0.0/23" "10.0.1.0/24"}}`, Result: "true"},
+	"ip4_aggregate":    {Signature: "ip4_aggregate PREFIXES", Example: `{{ip4_aggregate "10.0.1.0/24,10.0.0.0/24,10.0.0.128/25"}}`, Result: "[10.0.0.0/23]"},
+	"ip4_prefix_list":  {Signature: "ip4_prefix_list NAME GE LE PREFIXES", Example: `{{range ip4_prefix_list "LAN" 0 32 "10.0.0.0/23"}}{{.}}{{end}}`, Result: "ip prefix-list LAN seq 5 permit 10.0.0.0/23 le 32"},
+	"ifrange_expand":   {Signature: "ifrange_expand EXPR", Example: `{{ifrange_expand "Gi1/0/1-3,ge-0/0/[0-1]"}}`, Result: "[Gi1/0/1 Gi1/0/2 Gi1/0/3 ge-0/0/0 ge-0/0/1]"},
+	"ifrange_compress": {Signature: "ifrange_compress VENDOR LIST", Example: `{{ifrange_expand "Gi1/0/1-3,Gi1/0/5" | ifrange_compress "cisco" | join ", "}}`, Result: "Gi1/0/1 - 3, Gi1/0/5"},
+	"vlan_expand":      {Signature: "vlan_expand VLANS", Example: `{{vlan_expand "30,10,20-22"}}`, Result: "[10 20 21 22 30]"},
+	"vlan_compress":    {Signature: "vlan_compress VLANS", Example: `{{list 1 2 3 5 | vlan_compress}}`, Result: "1-3,5"},
+	"vlan_union":       {Signature: "vlan_union VLANS VLANS", Example: `{{vlan_union "1-3" "5" | vlan_compress}}`, Result: "1-3,5"},
+	"vlan_diff":        {Signature: "vlan_diff VLANS VLANS", Example: `{{vlan_diff "1-10" "5" | vlan_compress}}`, Result: "1-4,6-10"},
+	"vlan_intersect":   {Signature: "vlan_intersect VLANS VLANS", Example: `{{vlan_intersect "1-10" "5-20" | vlan_compress}}`, Result: "5-10"},
+	"vlan_wrap":        {Signature: "vlan_wrap VENDOR VLANS", Example: `{{range vlan_wrap "cisco" "10,20-30"}}{{.}}{{end}}`, Result: "switchport trunk allowed vlan 10,20-30"},
+	"mac":              {Signature: "mac MAC", Example: `{{mac "AABB.CCDD.EEFF"}}`, Result: "aa:bb:cc:dd:ee:ff"},
+	"mac_format":       {Signature: "mac_format FORMAT MAC", Example: `{{"aa:bb:cc:dd:ee:ff" | mac_format "cisco"}}`, Result: "aabb.ccdd.eeff"},
+	"mac_offset":       {Signature: "mac_offset N MAC", Example: `{{"aa:bb:cc:dd:ee:ff" | mac_offset 2}}`, Result: "aa:bb:cc:dd:ef:01"},
+	"mac_oui":          {Signature: "mac_oui MAC", Example: `{{mac_oui "AA-BB-CC-DD-EE-FF"}}`, Result: "aa:bb:cc"},
+	"mac_eui64":        {Signature: "mac_eui64 MAC", Example: `{{mac_eui64 "aa:bb:cc:dd:ee:ff"}}`, Result: "a8bb:ccff:fedd:eeff"},
+	"lookup":           {Signature: "lookup COLUMN VALUE RETCOLUMN", Example: `{{lookup "hostname" "r2" "loopback"}}`, Result: "10.255.0.2"},
+	"lookup_row":       {Signature: "lookup_row COLUMN VALUE", Example: `{{(lookup_row "hostname" "r2").loopback}}`, Result: "10.255.0.2"},
+	"where":            {Signature: "where COLUMN VALUE", Example: `{{range where "site" "waw"}}{{.hostname}} {{end}}`, Result: "r1 r2 "},
+	"group_by":         {Signature: "group_by COLUMN", Example: `{{range $site, $rows := group_by "site"}}{{$site}}:{{len $rows}} {{end}}`, Result: "krk:1 waw:2 "},
+	"include":          {Signature: "include NAME DATA", Example: `{{define "x"}}[{{.}}]{{end}}{{include "x" "a"}}`, Result: "[a]"},
+	"indent":           {Signature: "indent N STR", Example: `{{indent 2 "a"}}`, Result: "  a"},
+	"nindent":          {Signature: "nindent N STR", Example: `{{nindent 2 "a"}}`, Result: "\n  a"},
+
+	"upper":         {Signature: "upper STR", Example: `{{"acme" | upper}}`, Result: "ACME"},
+	"lower":         {Signature: "lower STR", Example: `{{"ACME" | lower}}`, Result: "acme"},
+	"title":         {Signature: "title STR", Example: `{{"core switch" | title}}`, Result: "Core Switch"},
+	"trim":          {Signature: "trim STR", Example: `{{" r1 " | trim}}`, Result: "r1"},
+	"trim_all":      {Signature: "trim_all CUTSET STR", Example: `{{"--r1--" | trim_all "-"}}`, Result: "r1"},
+	"trim_prefix":   {Signature: "trim_prefix PREFIX STR", Example: `{{"Gi0/1" | trim_prefix "Gi"}}`, Result: "0/1"},
+	"trim_suffix":   {Signature: "trim_suffix SUFFIX STR", Example: `{{"r1.acme.com" | trim_suffix ".acme.com"}}`, Result: "r1"},
+	"replace":       {Signature: "replace OLD NEW STR", Example: `{{"r1-waw" | replace "-" "_"}}`, Result: "r1_waw"},
+	"contains":      {Signature: "contains SUBSTR STR", Example: `{{"core-sw1" | contains "core"}}`, Result: "true"},
+	"has_prefix":    {Signature: "has_prefix PREFIX STR", Example: `{{"Gi0/1" | has_prefix "Gi"}}`, Result: "true"},
+	"has_suffix":    {Signature: "has_suffix SUFFIX STR", Example: `{{"Gi0/1" | has_suffix "/1"}}`, Result: "true"},
+	"repeat":        {Signature: "repeat N STR", Example: `{{"-" | repeat 3}}`, Result: "---"},
+	"pad_left":      {Signature: "pad_left WIDTH STR", Example: `{{"7" | pad_left 3}}`, Result: "  7"},
+	"pad_right":     {Signature: "pad_right WIDTH STR", Example: `[{{"7" | pad_right 3}}]`, Result: "[7  ]"},
+	"regex_match":   {Signature: "regex_match REGEX STR", Example: `{{"Gi0/1" | regex_match "^Gi"}}`, Result: "true"},
+	"regex_replace": {Signature: "regex_replace REGEX REPL STR", Example: `{{"Gi0/1" | regex_replace "^Gi" "GigabitEthernet"}}`, Result: "GigabitEthernet0/1"},
+	"quote":         {Signature: "quote STR", Example: `{{"ACME" | quote}}`, Result: `"ACME"`},
+	"split_list":    {Signature: "split_list SEP STR", Example: `{{range split_list "," "a,b"}}[{{.}}]{{end}}`, Result: "[a][b]"},
+	"join":          {Signature: "join SEP LIST", Example: `{{list "a" "b" | join ","}}`, Result: "a,b"},
+	"to_string":     {Signature: "to_string VALUE", Example: `{{to_string 10}}`, Result: "10"},
+	"atoi":          {Signature: "atoi VALUE", Example: `{{atoi "10" | add 1}}`, Result: "11"},
+
+	"default":  {Signature: "default DEFAULT VALUE", Example: `{{"" | default "none"}}`, Result: "none"},
+	"empty":    {Signature: "empty VALUE", Example: `{{empty ""}}`, Result: "true"},
+	"coalesce": {Signature: "coalesce VALUE...", Example: `{{coalesce "" "b" "c"}}`, Result: "b"},
+	"ternary":  {Signature: "ternary A B COND", Example: `{{ternary "up" "down" true}}`, Result: "up"},
+	"fail":     {Signature: "fail MSG", Example: `{{if false}}{{fail "no uplink"}}{{end}}`, Result: ""},
+
+	"list":       {Signature: "list VALUE...", Example: `{{list 1 2 3}}`, Result: "[1 2 3]"},
+	"first":      {Signature: "first LIST", Example: `{{list 1 2 3 | first}}`, Result: "1"},
+	"last":       {Signature: "last LIST", Example: `{{list 1 2 3 | last}}`, Result: "3"},
+	"rest":       {Signature: "rest LIST", Example: `{{list 1 2 3 | rest}}`, Result: "[2 3]"},
+	"append":     {Signature: "append LIST VALUE", Example: `{{append (list 1 2) 3}}`, Result: "[1 2 3]"},
+	"has":        {Signature: "has VALUE LIST", Example: `{{list "a" "b" | has "b"}}`, Result: "true"},
+	"uniq":       {Signature: "uniq LIST", Example: `{{list 1 2 1 | uniq}}`, Result: "[1 2]"},
+	"reverse":    {Signature: "reverse LIST", Example: `{{list 1 2 3 | reverse}}`, Result: "[3 2 1]"},
+	"sort_alpha": {Signature: "sort_alpha LIST", Example: `{{list "b" "a" | sort_alpha}}`, Result: "[a b]"},
+
+	"dict":    {Signature: "dict KEY VALUE...", Example: `{{(dict "vlan" 10).vlan}}`, Result: "10"},
+	"get":     {Signature: "get DICT KEY", Example: `{{get (dict "vlan" 10) "vlan"}}`, Result: "10"},
+	"has_key": {Signature: "has_key DICT KEY", Example: `{{has_key (dict "vlan" 10) "vlan"}}`, Result: "true"},
+	"keys":    {Signature: "keys DICT", Example: `{{keys (dict "b" 1 "a" 2)}}`, Result: "[a b]"},
+
+	"add": {Signature: "add NUM...", Example: `{{add 1 "2" 3}}`, Result: "6"},
+	"sub": {Signature: "sub A B", Example: `{{sub 10 3}}`, Result: "7"},
+	"mul": {Signature: "mul NUM...", Example: `{{mul 2 3}}`, Result: "6"},
+	"div": {Signature: "div A B", Example: `{{div 10 3}}`, Result: "3"},
+	"mod": {Signature: "mod A B", Example: `{{mod 10 3}}`, Result: "1"},
+	"max": {Signature: "max NUM...", Example: `{{max 1 5 3}}`, Result: "5"},
+	"min": {Signature: "min NUM...", Example: `{{min 4 2 3}}`, Result: "2"},
+	"seq": {Signature: "seq START END", Example: `{{seq 1 3}}`, Result: "[1 2 3]"},
+
+	"sha256sum": {Signature: "sha256sum VALUE", Example: `{{"r1" | sha256sum}}`, Result: "82f3e9c695dc6b8d1b11818d5701919e286de8d47f7c3eb3100c485f79e57828"},
+	"md5sum":    {Signature: "md5sum VALUE", Example: `{{"r1" | md5sum}}`, Result: "7c92cf1eee8d99cc85f8355a3d6e4b86"},
+	"crc32sum":  {Signature: "crc32sum VALUE", Example: `{{"r1" | crc32sum}}`, Result: "0d0e09b1"},
+	"uuidv5":    {Signature: "uuidv5 NAMESPACE VALUE", Example: `{{"r1.acme.com" | uuidv5 "dns"}}`, Result: "e6a7342b-8d00-5371-8477-e663ba2471ee"},
+	"hashmod":   {Signature: "hashmod MIN MAX KEY", Example: `{{"r1" | hashmod 1 255}}`, Result: "147"},
+
+	"date":         {Signature: "date LAYOUT DATE", Example: `{{"2019-03-08T14:30:00Z" | date "02.01.2006 15:04"}}`, Result: "08.03.2019 14:30"},
+	"date_in_zone": {Signature: "date_in_zone LAYOUT ZONE DATE", Example: `{{"2019-03-08T14:30:00+01:00" | date_in_zone "15:04 MST" "UTC"}}`, Result: "13:30 UTC"},
+	"to_date":      {Signature: "to_date LAYOUT STR", Example: `{{(to_date "02.01.2006" "08.03.2019").Year}}`, Result: "2019"},
+	"date_add":     {Signature: "date_add DURATION DATE", Example: `{{"2019-03-08" | date_add "90d" | date "2006-01-02"}}`, Result: "2019-06-06"},
+	"unix_epoch":   {Signature: "unix_epoch DATE", Example: `{{"2019-03-08T00:00:00Z" | unix_epoch}}`, Result: "1552003200"},
+}
+
+// FuncDocs returns descriptions of all functions available in templates sorted by name.
+// Signature of a function without description is derived from its type
+func FuncDocs() []FuncDoc {
+	docs := make([]FuncDoc, 0, len(templateFuncs))
+
+	for name, fn := range templateFuncs {
+		doc, ok := funcDocs[name]
+		if !ok {
+			doc.Signature = name + " " + reflect.TypeOf(fn).String()
+		}
+		doc.Name = name
+
+		docs = append(docs, doc)
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].Name < docs[j].Name
+	})
+
+	return docs
+}
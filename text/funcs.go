@@ -16,29 +16,125 @@ package text
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/dspinhirne/netaddr-go"
 )
 
+// templateFuncs holds functions available in templates, names made of several words are in snake case (ip4mask_to_cidr)
 var templateFuncs = map[string]interface{}{
-	"split":           Split,
-	"ip4":             IP4,
-	"ip4mask":         IP4Mask,
-	"ip4cidr":         IP4Cidr,
-	"ip4mask_to_cidr": IP4MaskToCidr,
-	"ip4cidr_to_mask": IP4CidrToMask,
+	"split":            Split,
+	"ip4":              IP4,
+	"ip4mask":          IP4Mask,
+	"ip4cidr":          IP4Cidr,
+	"ip4mask_to_cidr":  IP4MaskToCidr,
+	"ip4cidr_to_mask":  IP4CidrToMask,
+	"ip4wildcard":      IP4Wildcard,
+	"ip4_contains":     IP4Contains,
+	"ip4_overlaps":     IP4Overlaps,
+	"ip4_aggregate":    IP4Aggregate,
+	"ip4_prefix_list":  IP4PrefixList,
+	"include":          include,
+	"lookup":           noDataset.Lookup,
+	"lookup_row":       noDataset.LookupRow,
+	"where":            noDataset.Where,
+	"group_by":         noDataset.GroupBy,
+	"indent":           Indent,
+	"nindent":          NIndent,
+	"ifrange_expand":   IfRangeExpand,
+	"ifrange_compress": IfRangeCompress,
+	"vlan_expand":      VlanExpand,
+	"vlan_compress":    VlanCompress,
+	"vlan_union":       VlanUnion,
+	"vlan_diff":        VlanDiff,
+	"vlan_intersect":   VlanIntersect,
+	"vlan_wrap":        VlanWrap,
+	"mac":              MAC,
+	"mac_format":       MACFormat,
+	"mac_offset":       MACOffset,
+	"mac_oui":          MACOUI,
+	"mac_eui64":        MACEUI64,
+
+	"upper":         Upper,
+	"lower":         Lower,
+	"title":         Title,
+	"trim":          Trim,
+	"trim_all":      TrimAll,
+	"trim_prefix":   TrimPrefix,
+	"trim_suffix":   TrimSuffix,
+	"replace":       Replace,
+	"contains":      Contains,
+	"has_prefix":    HasPrefix,
+	"has_suffix":    HasSuffix,
+	"repeat":        Repeat,
+	"pad_left":      PadLeft,
+	"pad_right":     PadRight,
+	"regex_match":   RegexMatch,
+	"regex_replace": RegexReplace,
+	"quote":         Quote,
+	"split_list":    SplitList,
+	"join":          Join,
+	"to_string":     ToString,
+	"atoi":          Atoi,
+
+	"default":  Default,
+	"empty":    Empty,
+	"coalesce": Coalesce,
+	"ternary":  Ternary,
+	"fail":     Fail,
+
+	"list":       List,
+	"first":      First,
+	"last":       Last,
+	"rest":       Rest,
+	"append":     Append,
+	"has":        Has,
+	"uniq":       Uniq,
+	"reverse":    Reverse,
+	"sort_alpha": SortAlpha,
+
+	"dict":    Dict,
+	"get":     Get,
+	"has_key": HasKey,
+	"keys":    Keys,
+
+	"add": Add,
+	"sub": Sub,
+	"mul": Mul,
+	"div": Div,
+	"mod": Mod,
+	"max": Max,
+	"min": Min,
+	"seq": Seq,
+
+	"sha256sum": Sha256Sum,
+	"md5sum":    MD5Sum,
+	"crc32sum":  CRC32Sum,
+	"uuidv5":    UUIDv5,
+	"hashmod":   HashMod,
+
+	"date":         Date,
+	"date_in_zone": DateInZone,
+	"to_date":      ToDate,
+	"date_add":     DateAdd,
+	"unix_epoch":   UnixEpoch,
 	//"ip6":      IP6,
 	//"ip6mask": IP6Mask,
 }
 
-func IP4(ip string, idx int) (string, error) {
+// IP4 returns idx-th address of a given prefix, idx may be a number or a string containing a number
+func IP4(ip interface{}, n interface{}) (string, error) {
+	idx, err := toInt(n)
+	if err != nil {
+		return "", err
+	}
 	if idx < 0 {
 		return "", fmt.Errorf("negative value of argument passed to ip4 func not allowed")
 	}
 
-	ipv4net, err := netaddr.ParseIPv4Net(ip)
+	ipv4net, err := netaddr.ParseIPv4Net(ToString(ip))
 	if err != nil {
 		return "", err
 	}
@@ -52,8 +148,8 @@ func IP4(ip string, idx int) (string, error) {
 	return ipv4.String(), nil
 }
 
-func IP4Mask(ip string) (string, error) {
-	ipv4net, err := netaddr.ParseIPv4Net(ip)
+func IP4Mask(ip interface{}) (string, error) {
+	ipv4net, err := netaddr.ParseIPv4Net(ToString(ip))
 	if err != nil {
 		return "", err
 	}
@@ -61,8 +157,126 @@ func IP4Mask(ip string) (string, error) {
 	return ipv4net.Netmask().Extended(), nil
 }
 
-func IP4Cidr(ip string) (string, error) {
-	ipv4net, err := netaddr.ParseIPv4Net(ip)
+// IP4Wildcard returns wildcard mask (inverse of a netmask) of a given prefix, e.g. 0.0.0.255 for 10.0.0.0/24
+func IP4Wildcard(ip interface{}) (string, error) {
+	ipv4net, err := netaddr.ParseIPv4Net(ToString(ip))
+	if err != nil {
+		return "", err
+	}
+
+	return netaddr.NewIPv4(^ipv4net.Netmask().Mask()).String(), nil
+}
+
+// IP4Contains reports whether prefix contains a given address or prefix
+func IP4Contains(prefix interface{}, ip interface{}) (bool, error) {
+	ipv4net, err := netaddr.ParseIPv4Net(ToString(prefix))
+	if err != nil {
+		return false, err
+	}
+	other, err := netaddr.ParseIPv4Net(ToString(ip))
+	if err != nil {
+		return false, err
+	}
+
+	related, rel := ipv4net.Rel(other)
+
+	return related && rel >= 0, nil
+}
+
+// IP4Overlaps reports whether two prefixes have any address in common
+func IP4Overlaps(a interface{}, b interface{}) (bool, error) {
+	netA, err := netaddr.ParseIPv4Net(ToString(a))
+	if err != nil {
+		return false, err
+	}
+	netB, err := netaddr.ParseIPv4Net(ToString(b))
+	if err != nil {
+		return false, err
+	}
+
+	related, _ := netA.Rel(netB)
+
+	return related, nil
+}
+
+// ip4Prefixes converts comma separated string or a list of prefixes to a list of networks
+func ip4Prefixes(prefixes interface{}) (netaddr.IPv4NetList, error) {
+	list := toStrings(prefixes)
+	if s, ok := prefixes.(string); ok {
+		list = SplitList(",", s)
+	}
+
+	var nets netaddr.IPv4NetList
+	for _, prefix := range list {
+		prefix = strings.TrimSpace(prefix)
+		if prefix == "" {
+			continue
+		}
+
+		ipv4net, err := netaddr.ParseIPv4Net(prefix)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipv4net)
+	}
+
+	return nets, nil
+}
+
+// IP4Aggregate returns the minimal sorted list of prefixes covering all given prefixes (comma separated string or a list)
+func IP4Aggregate(prefixes interface{}) ([]string, error) {
+	nets, err := ip4Prefixes(prefixes)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregated := []string{}
+	for _, ipv4net := range nets.Summ() {
+		aggregated = append(aggregated, ipv4net.String())
+	}
+
+	return aggregated, nil
+}
+
+// IP4PrefixList returns 'ip prefix-list' entries permitting given prefixes (comma separated string or a list).
+// Entries are numbered by 5 and 'ge'/'le' are added when they are greater than 0
+func IP4PrefixList(name string, ge interface{}, le interface{}, prefixes interface{}) ([]string, error) {
+	bounds, err := ints(ge, le)
+	if err != nil {
+		return nil, err
+	}
+
+	nets, err := ip4Prefixes(prefixes)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []string{}
+	for i, ipv4net := range nets {
+		prefixLen := int(ipv4net.Netmask().PrefixLen())
+		entry := fmt.Sprintf("ip prefix-list %s seq %d permit %s", name, 5*(i+1), ipv4net)
+
+		if bounds[0] > 0 {
+			if bounds[0] <= prefixLen || bounds[0] > 32 {
+				return nil, fmt.Errorf("'ge %d' is not valid for prefix %s", bounds[0], ipv4net)
+			}
+			entry += fmt.Sprintf(" ge %d", bounds[0])
+		}
+		if bounds[1] > 0 {
+			if bounds[1] <= prefixLen || bounds[1] > 32 || bounds[1] < bounds[0] {
+				return nil, fmt.Errorf("'le %d' is not valid for prefix %s", bounds[1], ipv4net)
+			}
+			entry += fmt.Sprintf(" le %d", bounds[1])
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func IP4Cidr(ip interface{}) (string, error) {
+	ipv4net, err := netaddr.ParseIPv4Net(ToString(ip))
 	if err != nil {
 		return "", err
 	}
@@ -83,8 +297,8 @@ func IP4MaskToCidr(mask string) (string, error) {
 	return cidr, nil
 }
 
-func IP4CidrToMask(cidr string) (string, error) {
-	cidrInt, err := strconv.Atoi(cidr)
+func IP4CidrToMask(cidr interface{}) (string, error) {
+	cidrInt, err := toInt(cidr)
 	if err != nil {
 		return "", err
 	}
@@ -97,11 +311,215 @@ func IP4CidrToMask(cidr string) (string, error) {
 	return mask32.Extended(), nil
 }
 
-func Split(str string, sep string, idx int) string {
+// Split splits str with sep and returns idx-th element, idx may be a number or a string containing a number
+func Split(str string, sep string, n interface{}) string {
+	idx, err := toInt(n)
+	if err != nil {
+		return ""
+	}
+
 	arr := strings.Split(str, sep)
-	if idx > len(arr)-1 {
+	if idx < 0 || idx > len(arr)-1 {
 		return ""
 	}
 
 	return arr[idx]
 }
+
+// vlanLineLen is a maximum length of a list of VLANs in a single line of a trunk configuration
+const vlanLineLen = 60
+
+// VlanExpand parses VLAN (or any number) range string, e.g. '10,20-30,100', and returns sorted list of numbers without duplicates
+func VlanExpand(vlans string) ([]int, error) {
+	var nums []int
+
+	for _, item := range strings.Split(vlans, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		bounds := strings.SplitN(item, "-", 2)
+		if len(bounds) == 1 {
+			bounds = append(bounds, bounds[0])
+		}
+
+		r, err := numRange(strings.TrimSpace(bounds[0]), strings.TrimSpace(bounds[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid range '%s': %s", item, err)
+		}
+		nums = append(nums, r...)
+	}
+
+	list := []int{}
+	for _, r := range compressNums(nums) {
+		for n := r[0]; n <= r[1]; n++ {
+			list = append(list, n)
+		}
+	}
+
+	return list, nil
+}
+
+// vlanSet converts range string or a list of numbers to a set of numbers
+func vlanSet(vlans interface{}) (map[int]bool, error) {
+	var nums []int
+
+	if s, ok := vlans.(string); ok {
+		var err error
+
+		nums, err = VlanExpand(s)
+		if err != nil {
+			return nil, err
+		}
+	} else if vlans != nil {
+		list, err := toList(vlans)
+		if err != nil {
+			return nil, err
+		}
+
+		nums, err = ints(list...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	set := make(map[int]bool, len(nums))
+	for _, n := range nums {
+		set[n] = true
+	}
+
+	return set, nil
+}
+
+// sortedSet returns sorted elements of a set
+func sortedSet(set map[int]bool) []int {
+	nums := make([]int, 0, len(set))
+	for n := range set {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+
+	return nums
+}
+
+// VlanCompress renders VLANs (range string or a list of numbers) as a compact range string, e.g. '10,20-30,100'
+func VlanCompress(vlans interface{}) (string, error) {
+	set, err := vlanSet(vlans)
+	if err != nil {
+		return "", err
+	}
+
+	var ranges []string
+	for _, r := range compressNums(sortedSet(set)) {
+		if r[0] == r[1] {
+			ranges = append(ranges, strconv.Itoa(r[0]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", r[0], r[1]))
+		}
+	}
+
+	return strings.Join(ranges, ","), nil
+}
+
+// VlanUnion returns sorted list of VLANs present in a or b
+func VlanUnion(a, b interface{}) ([]int, error) {
+	setA, err := vlanSet(a)
+	if err != nil {
+		return nil, err
+	}
+	setB, err := vlanSet(b)
+	if err != nil {
+		return nil, err
+	}
+
+	for n := range setB {
+		setA[n] = true
+	}
+
+	return sortedSet(setA), nil
+}
+
+// VlanDiff returns sorted list of VLANs present in a, but not in b
+func VlanDiff(a, b interface{}) ([]int, error) {
+	setA, err := vlanSet(a)
+	if err != nil {
+		return nil, err
+	}
+	setB, err := vlanSet(b)
+	if err != nil {
+		return nil, err
+	}
+
+	for n := range setB {
+		delete(setA, n)
+	}
+
+	return sortedSet(setA), nil
+}
+
+// VlanIntersect returns sorted list of VLANs present both in a and b
+func VlanIntersect(a, b interface{}) ([]int, error) {
+	setA, err := vlanSet(a)
+	if err != nil {
+		return nil, err
+	}
+	setB, err := vlanSet(b)
+	if err != nil {
+		return nil, err
+	}
+
+	for n := range setA {
+		if !setB[n] {
+			delete(setA, n)
+		}
+	}
+
+	return sortedSet(setA), nil
+}
+
+// VlanWrap returns lines of trunk's allowed VLANs configuration for a given vendor. For cisco, nxos and arista
+// VLANs are wrapped into 'switchport trunk allowed vlan add' continuation lines, for junos a single 'vlan members' line is returned
+func VlanWrap(vendor string, vlans interface{}) ([]string, error) {
+	compressed, err := VlanCompress(vlans)
+	if err != nil {
+		return nil, err
+	}
+
+	switch vendor {
+	case "cisco", "nxos", "arista":
+	case "junos":
+		return []string{"vlan members [ " + strings.Replace(compressed, ",", " ", -1) + " ]"}, nil
+	default:
+		return nil, fmt.Errorf("unknown vendor '%s', expected one of: cisco, nxos, arista, junos", vendor)
+	}
+
+	if compressed == "" {
+		return []string{"switchport trunk allowed vlan none"}, nil
+	}
+
+	var chunks []string
+	var chunk string
+	for _, r := range strings.Split(compressed, ",") {
+		if chunk != "" && len(chunk)+1+len(r) > vlanLineLen {
+			chunks = append(chunks, chunk)
+			chunk = ""
+		}
+		if chunk != "" {
+			chunk += ","
+		}
+		chunk += r
+	}
+	chunks = append(chunks, chunk)
+
+	lines := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		if i == 0 {
+			lines[i] = "switchport trunk allowed vlan " + chunk
+		} else {
+			lines[i] = "switchport trunk allowed vlan add " + chunk
+		}
+	}
+
+	return lines, nil
+}
@@ -14,7 +14,12 @@
 
 package text
 
-import "testing"
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
 
 func TestIP4(t *testing.T) {
 	var ip4TestCases = []struct {
@@ -149,3 +154,255 @@ func TestIP4MaskToCidr(t *testing.T) {
 		}
 	}
 }
+
+func TestVlanExpand(t *testing.T) {
+	var testCases = []struct {
+		vlans    string
+		expected []int
+	}{
+		{"10,20-23,100", []int{10, 20, 21, 22, 23, 100}},
+		{"100, 10, 20 - 21, 10-11", []int{10, 11, 20, 21, 100}},
+		{"", []int{}},
+	}
+
+	for _, tc := range testCases {
+		result, err := VlanExpand(tc.vlans)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("expected to get %v, instead got %v", tc.expected, result)
+		}
+	}
+
+	for _, tc := range []string{"10-a", "30-20", "1-100000"} {
+		if _, err := VlanExpand(tc); err == nil {
+			t.Errorf("expected to get an error for '%s', instead got nil", tc)
+		}
+	}
+}
+
+func TestVlanCompress(t *testing.T) {
+	var testCases = []struct {
+		vlans    interface{}
+		expected string
+	}{
+		{"100,10,11,12,20", "10-12,20,100"},
+		{[]int{3, 1, 2, 2, 7}, "1-3,7"},
+		{[]interface{}{"5", 6, 8}, "5-6,8"},
+		{[]string{}, ""},
+	}
+
+	for _, tc := range testCases {
+		result, err := VlanCompress(tc.vlans)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if result != tc.expected {
+			t.Errorf("expected to get '%s', instead got '%s'", tc.expected, result)
+		}
+	}
+}
+
+func TestVlanSets(t *testing.T) {
+	var testCases = []struct {
+		fn       func(a, b interface{}) ([]int, error)
+		a        interface{}
+		b        interface{}
+		expected []int
+	}{
+		{VlanUnion, "1-3", []int{3, 5}, []int{1, 2, 3, 5}},
+		{VlanDiff, "1-5", "2,4", []int{1, 3, 5}},
+		{VlanIntersect, "1-5", "4-8", []int{4, 5}},
+		{VlanIntersect, "1-5", "", []int{}},
+	}
+
+	for _, tc := range testCases {
+		result, err := tc.fn(tc.a, tc.b)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("expected to get %v, instead got %v", tc.expected, result)
+		}
+	}
+}
+
+func TestVlanWrap(t *testing.T) {
+	var evenVlans []string
+	for i := 2; i <= 60; i += 2 {
+		evenVlans = append(evenVlans, strconv.Itoa(i))
+	}
+
+	lines, err := VlanWrap("cisco", strings.Join(evenVlans, ","))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"switchport trunk allowed vlan 2,4,6,8,10,12,14,16,18,20,22,24,26,28,30,32,34,36,38,40,42",
+		"switchport trunk allowed vlan add 44,46,48,50,52,54,56,58,60",
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected to get %q, instead got %q", expected, lines)
+	}
+
+	lines, err = VlanWrap("junos", "10,20-30")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(lines, []string{"vlan members [ 10 20-30 ]"}) {
+		t.Errorf("expected to get vlan members line, instead got %q", lines)
+	}
+
+	if _, err = VlanWrap("nokia", "10"); err == nil {
+		t.Error("expected to get an error for unknown vendor, instead got nil")
+	}
+}
+
+func TestIP4Wildcard(t *testing.T) {
+	var testCases = []struct {
+		ip       string
+		expected string
+	}{
+		{"10.0.0.0/24", "0.0.0.255"},
+		{"10.0.0.0/32", "0.0.0.0"},
+		{"10.0.0.0/8", "0.255.255.255"},
+		{"10.0.0.0/27", "0.0.0.31"},
+	}
+
+	for _, tc := range testCases {
+		result, err := IP4Wildcard(tc.ip)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if result != tc.expected {
+			t.Errorf("expected to get '%s', instead got '%s'\n", tc.expected, result)
+		}
+	}
+}
+
+func TestIP4ContainsOverlaps(t *testing.T) {
+	var testCases = []struct {
+		a        string
+		b        string
+		contains bool
+		overlaps bool
+	}{
+		{"10.0.0.0/8", "10.1.2.3", true, true},
+		{"10.0.0.0/8", "10.1.0.0/16", true, true},
+		{"10.0.0.0/24", "10.0.0.0/24", true, true},
+		{"10.1.0.0/16", "10.0.0.0/8", false, true},
+		{"10.0.0.0/24", "10.0.1.0/24", false, false},
+	}
+
+	for _, tc := range testCases {
+		contains, err := IP4Contains(tc.a, tc.b)
+		if err != nil {
+			t.Error(err)
+		}
+		if contains != tc.contains {
+			t.Errorf("expected ip4_contains of %s and %s to be %t, instead got %t", tc.a, tc.b, tc.contains, contains)
+		}
+
+		overlaps, err := IP4Overlaps(tc.a, tc.b)
+		if err != nil {
+			t.Error(err)
+		}
+		if overlaps != tc.overlaps {
+			t.Errorf("expected ip4_overlaps of %s and %s to be %t, instead got %t", tc.a, tc.b, tc.overlaps, overlaps)
+		}
+	}
+
+	if _, err := IP4Contains("10.0.0.0/8", "10.0.0"); err == nil {
+		t.Error("expected to get an error for invalid address, instead got nil")
+	}
+}
+
+func TestIP4Aggregate(t *testing.T) {
+	var testCases = []struct {
+		prefixes interface{}
+		expected []string
+	}{
+		{"10.0.0.0/24,10.0.1.0/24", []string{"10.0.0.0/23"}},
+		{[]string{"10.0.3.0/24", "10.0.0.0/24", "10.0.1.0/24", "10.0.0.64/26"}, []string{"10.0.0.0/23", "10.0.3.0/24"}},
+		{[]interface{}{"192.168.0.0/24"}, []string{"192.168.0.0/24"}},
+		{"", []string{}},
+	}
+
+	for _, tc := range testCases {
+		result, err := IP4Aggregate(tc.prefixes)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("expected to get %v, instead got %v", tc.expected, result)
+		}
+	}
+}
+
+func TestIP4PrefixList(t *testing.T) {
+	result, err := IP4PrefixList("LAN", 24, "28", "10.0.0.0/16, 10.1.0.0/16")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"ip prefix-list LAN seq 5 permit 10.0.0.0/16 ge 24 le 28",
+		"ip prefix-list LAN seq 10 permit 10.1.0.0/16 ge 24 le 28",
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected to get %q, instead got %q", expected, result)
+	}
+
+	var errorCases = []struct {
+		ge int
+		le int
+	}{
+		{16, 0},
+		{0, 33},
+		{28, 24},
+	}
+
+	for _, tc := range errorCases {
+		if _, err := IP4PrefixList("LAN", tc.ge, tc.le, "10.0.0.0/16"); err == nil {
+			t.Errorf("expected to get an error for ge %d le %d, instead got nil", tc.ge, tc.le)
+		}
+	}
+}
+
+func TestIP4FuncsInTemplates(t *testing.T) {
+	var testCases = []struct {
+		content  string
+		expected string
+	}{
+		{`{{ip4wildcard .prefix}}`, "0.0.3.255"},
+		{`{{ip4_contains .prefix "10.0.1.0/24"}} {{ip4_contains .prefix "10.0.4.1"}}`, "true false"},
+		{`{{ip4_overlaps .prefix "10.0.3.0/24"}} {{ip4_overlaps .prefix "10.1.0.0/16"}}`, "true false"},
+		{`{{ip4_aggregate "10.0.1.0/24,10.0.0.0/24" | join ","}}`, "10.0.0.0/23"},
+		{`{{range ip4_prefix_list "LAN" 24 .le .prefix}}{{.}}{{end}}`, "ip prefix-list LAN seq 5 permit 10.0.0.0/22 ge 24 le 28"},
+	}
+
+	for _, tc := range testCases {
+		tpl, err := NewTemplate(map[string]string{"prefix": "10.0.0.0/22", "le": "28"}, "test", strings.NewReader(tc.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := &strings.Builder{}
+		if err = tpl.Execute(w); err != nil {
+			t.Errorf("expected to execute '%s', instead got an error: %s", tc.content, err)
+			continue
+		}
+
+		if w.String() != tc.expected {
+			t.Errorf("expected to get '%s' from '%s', instead got '%s'", tc.expected, tc.content, w.String())
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"strings"
+)
+
+// uuidNamespaces are well-known namespaces of name-based UUIDs defined in RFC 4122
+var uuidNamespaces = map[string]string{
+	"dns":  "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+	"url":  "6ba7b811-9dad-11d1-80b4-00c04fd430c8",
+	"oid":  "6ba7b812-9dad-11d1-80b4-00c04fd430c8",
+	"x500": "6ba7b814-9dad-11d1-80b4-00c04fd430c8",
+}
+
+// Sha256Sum returns SHA-256 digest of a string representation of v in hex
+func Sha256Sum(v interface{}) string {
+	sum := sha256.Sum256([]byte(ToString(v)))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// MD5Sum returns MD5 digest of a string representation of v in hex
+func MD5Sum(v interface{}) string {
+	sum := md5.Sum([]byte(ToString(v)))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// CRC32Sum returns CRC-32 (IEEE) checksum of a string representation of v as 8 hex digits
+func CRC32Sum(v interface{}) string {
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(ToString(v))))
+}
+
+// parseUUID parses UUID in its canonical form or one of well-known namespaces: dns, url, oid, x500
+func parseUUID(s string) ([]byte, error) {
+	if ns, ok := uuidNamespaces[strings.ToLower(s)]; ok {
+		s = ns
+	}
+
+	b, err := hex.DecodeString(strings.Replace(s, "-", "", -1))
+	if err != nil || len(b) != 16 {
+		return nil, fmt.Errorf("invalid UUID: %s", s)
+	}
+
+	return b, nil
+}
+
+// UUIDv5 returns name-based UUID (version 5, SHA-1) of a string representation of v within a given namespace, which may
+// be a UUID or one of well-known namespaces: dns, url, oid, x500. The same namespace and name always give the same UUID
+func UUIDv5(namespace string, v interface{}) (string, error) {
+	ns, err := parseUUID(namespace)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha1.New()
+	h.Write(ns)
+	h.Write([]byte(ToString(v)))
+	u := h.Sum(nil)[:16]
+
+	// version 5 and RFC 4122 variant
+	u[6] = u[6]&0x0f | 0x50
+	u[8] = u[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16]), nil
+}
+
+// HashMod returns a number from min to max (both inclusive) derived from SHA-256 digest of a string representation of key.
+// The same key always gives the same number, e.g. VRRP group or route distinguisher of a device
+func HashMod(min interface{}, max interface{}, key interface{}) (int, error) {
+	bounds, err := ints(min, max)
+	if err != nil {
+		return 0, err
+	}
+	if bounds[0] > bounds[1] {
+		return 0, fmt.Errorf("beginning of range %d-%d is greater than its end", bounds[0], bounds[1])
+	}
+
+	sum := sha256.Sum256([]byte(ToString(key)))
+	span := uint64(bounds[1]-bounds[0]) + 1
+
+	return bounds[0] + int(binary.BigEndian.Uint64(sum[:8])%span), nil
+}
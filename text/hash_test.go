@@ -0,0 +1,72 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import "testing"
+
+func TestUUIDv5(t *testing.T) {
+	var testCases = []struct {
+		namespace string
+		name      interface{}
+		expected  string
+	}{
+		{"dns", "www.example.com", "2ed6657d-e927-568b-95e1-2665a8aea6a2"},
+		{"6ba7b810-9dad-11d1-80b4-00c04fd430c8", "www.example.com", "2ed6657d-e927-568b-95e1-2665a8aea6a2"},
+		{"URL", "http://www.example.com/", "fcde3c85-2270-590f-9e7c-ee003d65e0e2"},
+	}
+
+	for _, tc := range testCases {
+		result, err := UUIDv5(tc.namespace, tc.name)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if result != tc.expected {
+			t.Errorf("expected to get '%s', instead got '%s'", tc.expected, result)
+		}
+	}
+
+	if _, err := UUIDv5("6ba7b810-9dad", "r1"); err == nil {
+		t.Error("expected to get an error for invalid namespace, instead got nil")
+	}
+}
+
+func TestHashMod(t *testing.T) {
+	seen := make(map[int]bool)
+
+	for i := 0; i < 1000; i++ {
+		n, err := HashMod(1, 16, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n < 1 || n > 16 {
+			t.Errorf("expected to get a number from 1 to 16, instead got %d", n)
+		}
+		seen[n] = true
+
+		again, _ := HashMod("1", "16", i)
+		if again != n {
+			t.Errorf("expected to get the same number for the same key, instead got %d and %d", n, again)
+		}
+	}
+
+	if len(seen) != 16 {
+		t.Errorf("expected to get all 16 numbers, instead got %d", len(seen))
+	}
+
+	if _, err := HashMod(10, 1, "r1"); err == nil {
+		t.Error("expected to get an error for invalid range, instead got nil")
+	}
+}
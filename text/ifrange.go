@@ -0,0 +1,221 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxRangeLen limits number of elements produced by a single range expression
+const maxRangeLen = 4096
+
+// reIfBracket matches Junos range of interfaces, e.g. 'ge-0/0/[0-23]'
+var reIfBracket = regexp.MustCompile(`^([^\[\]]*)\[([0-9,\- ]+)\]([^\[\]]*)$`)
+
+// reIfDash matches Cisco and Arista range of interfaces, e.g. 'Gi1/0/1-24', 'Gi1/0/1 - 24' or 'Ethernet1-4'
+var reIfDash = regexp.MustCompile(`^(.*?)(\d+)\s*-\s*(\d+)$`)
+
+// reIfFullDash matches range of interfaces with full names on both sides, e.g. 'Gi1/0/1-Gi1/0/24'
+var reIfFullDash = regexp.MustCompile(`^(.*?)(\d+)\s*-\s*([^\d\s].*?)(\d+)$`)
+
+// reIfName splits name of an interface into a prefix and the last number, e.g. 'ge-0/0/' and '12'
+var reIfName = regexp.MustCompile(`^(.*?)(\d+)$`)
+
+// splitTopLevel splits s with commas which are not placed inside of brackets
+func splitTopLevel(s string) []string {
+	var items []string
+	var depth, start int
+
+	for i, ch := range s {
+		switch ch {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(items, s[start:])
+}
+
+// numRange returns all numbers from 'from' to 'to' (both inclusive)
+func numRange(from, to string) ([]int, error) {
+	start, err := strconv.Atoi(from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := strconv.Atoi(to)
+	if err != nil {
+		return nil, err
+	}
+
+	if start > end {
+		return nil, fmt.Errorf("beginning of range %d-%d is greater than its end", start, end)
+	}
+	if end-start >= maxRangeLen {
+		return nil, fmt.Errorf("range %d-%d is too long", start, end)
+	}
+
+	nums := make([]int, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		nums = append(nums, i)
+	}
+
+	return nums, nil
+}
+
+// IfRangeExpand expands range expression of interfaces into a list of interfaces. It understands Cisco
+// ('Gi1/0/1-24,Gi1/1/1 - 4'), Arista ('Ethernet1-4', 'Et1/1-4') and Junos ('ge-0/0/[0-23]', 'xe-0/0/[0-3,8]') syntax
+func IfRangeExpand(expr string) ([]string, error) {
+	interfaces := []string{}
+
+	for _, item := range splitTopLevel(expr) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if m := reIfBracket.FindStringSubmatch(item); m != nil {
+			for _, r := range strings.Split(m[2], ",") {
+				bounds := strings.SplitN(strings.TrimSpace(r), "-", 2)
+				if len(bounds) == 1 {
+					bounds = append(bounds, bounds[0])
+				}
+
+				nums, err := numRange(strings.TrimSpace(bounds[0]), strings.TrimSpace(bounds[1]))
+				if err != nil {
+					return nil, fmt.Errorf("invalid range of interfaces '%s': %s", item, err)
+				}
+				for _, n := range nums {
+					interfaces = append(interfaces, m[1]+strconv.Itoa(n)+m[3])
+				}
+			}
+			continue
+		}
+
+		m := reIfDash.FindStringSubmatch(item)
+		if full := reIfFullDash.FindStringSubmatch(item); full != nil {
+			if full[1] != full[3] {
+				return nil, fmt.Errorf("invalid range of interfaces '%s': both ends should be of the same type", item)
+			}
+			m = []string{full[0], full[1], full[2], full[4]}
+		}
+
+		if m != nil {
+			nums, err := numRange(m[2], m[3])
+			if err != nil {
+				return nil, fmt.Errorf("invalid range of interfaces '%s': %s", item, err)
+			}
+			for _, n := range nums {
+				interfaces = append(interfaces, m[1]+strconv.Itoa(n))
+			}
+			continue
+		}
+
+		if !reIfName.MatchString(item) {
+			return nil, fmt.Errorf("invalid name of interface '%s'", item)
+		}
+		interfaces = append(interfaces, item)
+	}
+
+	return interfaces, nil
+}
+
+// IfRangeCompress compresses a list of interfaces into range expressions of a given vendor (cisco, junos or arista).
+// Interfaces with the same prefix are sorted and consecutive ones are merged into a single range
+func IfRangeCompress(vendor string, list interface{}) ([]string, error) {
+	var prefixes []string
+	numbers := make(map[string][]int)
+
+	for _, name := range toStrings(list) {
+		name = strings.TrimSpace(name)
+
+		m := reIfName.FindStringSubmatch(name)
+		if m == nil {
+			return nil, fmt.Errorf("invalid name of interface '%s'", name)
+		}
+
+		n, err := strconv.Atoi(m[2])
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := numbers[m[1]]; !ok {
+			prefixes = append(prefixes, m[1])
+		}
+		numbers[m[1]] = append(numbers[m[1]], n)
+	}
+
+	var format func(prefix string, start, end int) string
+	switch vendor {
+	case "cisco":
+		format = func(prefix string, start, end int) string {
+			return fmt.Sprintf("%s%d - %d", prefix, start, end)
+		}
+	case "arista":
+		format = func(prefix string, start, end int) string {
+			return fmt.Sprintf("%s%d-%d", prefix, start, end)
+		}
+	case "junos":
+		format = func(prefix string, start, end int) string {
+			return fmt.Sprintf("%s[%d-%d]", prefix, start, end)
+		}
+	default:
+		return nil, fmt.Errorf("unknown vendor '%s', expected one of: cisco, junos, arista", vendor)
+	}
+
+	ranges := []string{}
+	for _, prefix := range prefixes {
+		for _, r := range compressNums(numbers[prefix]) {
+			if r[0] == r[1] {
+				ranges = append(ranges, prefix+strconv.Itoa(r[0]))
+			} else {
+				ranges = append(ranges, format(prefix, r[0], r[1]))
+			}
+		}
+	}
+
+	return ranges, nil
+}
+
+// compressNums sorts numbers, removes duplicates and returns consecutive ones as [start, end] ranges
+func compressNums(nums []int) [][2]int {
+	sorted := append([]int{}, nums...)
+	sort.Ints(sorted)
+
+	var ranges [][2]int
+	for _, n := range sorted {
+		last := len(ranges) - 1
+		switch {
+		case last >= 0 && n <= ranges[last][1]:
+			continue
+		case last >= 0 && n == ranges[last][1]+1:
+			ranges[last][1] = n
+		default:
+			ranges = append(ranges, [2]int{n, n})
+		}
+	}
+
+	return ranges
+}
@@ -0,0 +1,90 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIfRangeExpand(t *testing.T) {
+	var testCases = []struct {
+		expr     string
+		expected []string
+	}{
+		{"Gi1/0/1-3,Gi1/1/1 - 2", []string{"Gi1/0/1", "Gi1/0/2", "Gi1/0/3", "Gi1/1/1", "Gi1/1/2"}},
+		{"Gi1/0/1-Gi1/0/2", []string{"Gi1/0/1", "Gi1/0/2"}},
+		{"ge-0/0/[0-2], xe-1/0/[1,3-4]", []string{"ge-0/0/0", "ge-0/0/1", "ge-0/0/2", "xe-1/0/1", "xe-1/0/3", "xe-1/0/4"}},
+		{"Ethernet1-2,Et1/1-2,Et3", []string{"Ethernet1", "Ethernet2", "Et1/1", "Et1/2", "Et3"}},
+		{"ge-0/0/1", []string{"ge-0/0/1"}},
+		{"Gi0/1.100-101", []string{"Gi0/1.100", "Gi0/1.101"}},
+		{"", []string{}},
+	}
+
+	for _, tc := range testCases {
+		result, err := IfRangeExpand(tc.expr)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("expected to get %v from '%s', instead got %v", tc.expected, tc.expr, result)
+		}
+	}
+}
+
+func TestIfRangeExpandErrors(t *testing.T) {
+	var testCases = []string{
+		"Gi1/0/24-1",
+		"Gi1/0/1-Te1/0/4",
+		"ge-0/0/[0-9999]",
+		"mgmt",
+	}
+
+	for _, tc := range testCases {
+		_, err := IfRangeExpand(tc)
+		if err == nil {
+			t.Errorf("expected to get an error for '%s', instead got nil", tc)
+		}
+	}
+}
+
+func TestIfRangeCompress(t *testing.T) {
+	var testCases = []struct {
+		vendor   string
+		list     interface{}
+		expected []string
+	}{
+		{"cisco", []string{"Gi1/0/3", "Gi1/0/1", "Gi1/0/2", "Gi1/0/2", "Gi1/1/5"}, []string{"Gi1/0/1 - 3", "Gi1/1/5"}},
+		{"junos", []interface{}{"ge-0/0/0", "ge-0/0/1", "ge-0/0/3"}, []string{"ge-0/0/[0-1]", "ge-0/0/3"}},
+		{"arista", []string{"Ethernet1", "Ethernet2", "Ethernet4", "Ethernet5"}, []string{"Ethernet1-2", "Ethernet4-5"}},
+		{"cisco", []string{}, []string{}},
+	}
+
+	for _, tc := range testCases {
+		result, err := IfRangeCompress(tc.vendor, tc.list)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("expected to get %v, instead got %v", tc.expected, result)
+		}
+	}
+
+	if _, err := IfRangeCompress("nokia", []string{"1/1/1"}); err == nil {
+		t.Error("expected to get an error for unknown vendor, instead got nil")
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxMAC is the biggest 48-bit MAC address
+const maxMAC = 1<<48 - 1
+
+// reMAC matches all common notations of MAC address: aa:bb:cc:dd:ee:ff, aa-bb-cc-dd-ee-ff, aabb.ccdd.eeff and aabbccddeeff
+var reMAC = regexp.MustCompile(`^(?i)(?:[0-9a-f]{2}(?:[:-][0-9a-f]{2}){5}|[0-9a-f]{4}\.[0-9a-f]{4}\.[0-9a-f]{4}|[0-9a-f]{12})$`)
+
+// parseMAC parses MAC address in any of common notations and returns it as a number
+func parseMAC(mac string) (uint64, error) {
+	mac = strings.TrimSpace(mac)
+	if !reMAC.MatchString(mac) || strings.Contains(mac, ":") && strings.Contains(mac, "-") {
+		return 0, fmt.Errorf("invalid MAC address: %s", mac)
+	}
+
+	hex := strings.NewReplacer(":", "", "-", "", ".", "").Replace(mac)
+
+	return strconv.ParseUint(hex, 16, 64)
+}
+
+// formatMAC returns MAC address in a given notation
+func formatMAC(format string, mac uint64) (string, error) {
+	hex := fmt.Sprintf("%012x", mac)
+
+	octets := make([]string, 6)
+	for i := range octets {
+		octets[i] = hex[2*i : 2*i+2]
+	}
+
+	switch format {
+	case "colon", "unix":
+		return strings.Join(octets, ":"), nil
+	case "dash", "windows":
+		return strings.ToUpper(strings.Join(octets, "-")), nil
+	case "dot", "cisco":
+		return hex[0:4] + "." + hex[4:8] + "." + hex[8:12], nil
+	case "bare":
+		return hex, nil
+	}
+
+	return "", fmt.Errorf("unknown MAC address format '%s', expected one of: colon, unix, dash, windows, dot, cisco, bare", format)
+}
+
+// MAC parses MAC address in any of common notations and returns it as aa:bb:cc:dd:ee:ff
+func MAC(mac string) (string, error) {
+	m, err := parseMAC(mac)
+	if err != nil {
+		return "", err
+	}
+
+	return formatMAC("colon", m)
+}
+
+// MACFormat returns MAC address in a given notation: colon (or unix) - aa:bb:cc:dd:ee:ff, dash (or windows) - AA-BB-CC-DD-EE-FF,
+// dot (or cisco) - aabb.ccdd.eeff, bare - aabbccddeeff
+func MACFormat(format string, mac string) (string, error) {
+	m, err := parseMAC(mac)
+	if err != nil {
+		return "", err
+	}
+
+	return formatMAC(format, m)
+}
+
+// MACOffset returns MAC address which is n addresses after (or before, when n is negative) a given one
+func MACOffset(n interface{}, mac string) (string, error) {
+	offset, err := toInt(n)
+	if err != nil {
+		return "", err
+	}
+
+	m, err := parseMAC(mac)
+	if err != nil {
+		return "", err
+	}
+
+	result := int64(m) + int64(offset)
+	if result < 0 || result > maxMAC {
+		return "", fmt.Errorf("MAC address %s with offset %d is out of range", mac, offset)
+	}
+
+	return formatMAC("colon", uint64(result))
+}
+
+// MACOUI returns OUI (first three octets) of MAC address as aa:bb:cc
+func MACOUI(mac string) (string, error) {
+	m, err := parseMAC(mac)
+	if err != nil {
+		return "", err
+	}
+
+	colon, err := formatMAC("colon", m)
+	if err != nil {
+		return "", err
+	}
+
+	return colon[:8], nil
+}
+
+// MACEUI64 returns modified EUI-64 interface identifier derived from MAC address (as used by IPv6 SLAAC), e.g. a8bb:ccff:fedd:eeff
+func MACEUI64(mac string) (string, error) {
+	m, err := parseMAC(mac)
+	if err != nil {
+		return "", err
+	}
+
+	// ff:fe is inserted in the middle and universal/local bit is flipped
+	eui := (m>>24)<<40 | 0xfffe<<24 | m&0xffffff
+	eui ^= 1 << 57
+
+	hex := fmt.Sprintf("%016x", eui)
+
+	groups := make([]string, 4)
+	for i := range groups {
+		groups[i] = strings.TrimLeft(hex[4*i:4*i+4], "0")
+		if groups[i] == "" {
+			groups[i] = "0"
+		}
+	}
+
+	return strings.Join(groups, ":"), nil
+}
@@ -0,0 +1,127 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import "testing"
+
+func TestMACFormat(t *testing.T) {
+	var testCases = []struct {
+		mac      string
+		format   string
+		expected string
+	}{
+		{"aa:bb:cc:dd:ee:ff", "colon", "aa:bb:cc:dd:ee:ff"},
+		{"AA-BB-CC-DD-EE-FF", "unix", "aa:bb:cc:dd:ee:ff"},
+		{"aabb.ccdd.eeff", "dash", "AA-BB-CC-DD-EE-FF"},
+		{"AABBCCDDEEFF", "windows", "AA-BB-CC-DD-EE-FF"},
+		{"aa:bb:cc:dd:ee:ff", "cisco", "aabb.ccdd.eeff"},
+		{" 00:00:5e:00:53:01 ", "dot", "0000.5e00.5301"},
+		{"aa-bb-cc-dd-ee-ff", "bare", "aabbccddeeff"},
+	}
+
+	for _, tc := range testCases {
+		result, err := MACFormat(tc.format, tc.mac)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if result != tc.expected {
+			t.Errorf("expected to get '%s', instead got '%s'", tc.expected, result)
+		}
+	}
+}
+
+func TestMACErrors(t *testing.T) {
+	var testCases = []string{
+		"aa:bb:cc:dd:ee",
+		"aa:bb:cc:dd:ee:gg",
+		"aa:bb-cc:dd-ee:ff",
+		"aabb.ccdd.eeff.0011",
+		"",
+	}
+
+	for _, tc := range testCases {
+		if _, err := MAC(tc); err == nil {
+			t.Errorf("expected to get an error for '%s', instead got nil", tc)
+		}
+	}
+
+	if _, err := MACFormat("klingon", "aa:bb:cc:dd:ee:ff"); err == nil {
+		t.Error("expected to get an error for unknown format, instead got nil")
+	}
+}
+
+func TestMACOffset(t *testing.T) {
+	var testCases = []struct {
+		mac      string
+		offset   interface{}
+		expected string
+		isErr    bool
+	}{
+		{"aa:bb:cc:dd:ee:ff", 1, "aa:bb:cc:dd:ef:00", false},
+		{"aa:bb:cc:dd:ef:00", "-1", "aa:bb:cc:dd:ee:ff", false},
+		{"ff:ff:ff:ff:ff:ff", 1, "", true},
+		{"00:00:00:00:00:00", -1, "", true},
+	}
+
+	for _, tc := range testCases {
+		result, err := MACOffset(tc.offset, tc.mac)
+		if tc.isErr {
+			if err == nil {
+				t.Errorf("expected to get an error for '%s' with offset %v, instead got nil", tc.mac, tc.offset)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+		}
+
+		if result != tc.expected {
+			t.Errorf("expected to get '%s', instead got '%s'", tc.expected, result)
+		}
+	}
+}
+
+func TestMACOUI(t *testing.T) {
+	oui, err := MACOUI("0000.5e00.5301")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if oui != "00:00:5e" {
+		t.Errorf("expected to get '00:00:5e', instead got '%s'", oui)
+	}
+}
+
+func TestMACEUI64(t *testing.T) {
+	var testCases = []struct {
+		mac      string
+		expected string
+	}{
+		{"aa:bb:cc:dd:ee:ff", "a8bb:ccff:fedd:eeff"},
+		{"00:00:5e:00:53:01", "200:5eff:fe00:5301"},
+	}
+
+	for _, tc := range testCases {
+		result, err := MACEUI64(tc.mac)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if result != tc.expected {
+			t.Errorf("expected to get '%s', instead got '%s'", tc.expected, result)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetaKey is a reserved name under which Meta is available to templates
+const MetaKey = "Meta"
+
+// Meta holds information about the generation available to templates as .Meta, separately from rows and global variables
+type Meta struct {
+	// Time of the generation
+	Time time.Time
+	// Version of go-tmpl
+	Version string
+	// Workspace name
+	Workspace string
+	// DataFile is a name of CSV data file
+	DataFile string
+	// DataHash is SHA-256 digest of CSV data file in hex
+	DataHash string
+	// Commit is a git commit of the workspace, empty when workspace isn't a git repository
+	Commit string
+}
+
+// SetMeta sets information about the generation available to a template as .Meta
+func (t *Template) SetMeta(m Meta) {
+	t.meta = m
+}
+
+// UsedMeta reports whether the last execution of a template referred to .Meta, so its output depends on
+// the generation (e.g. its time) and not only on its rows
+func (t *Template) UsedMeta() bool {
+	return t.usedMeta
+}
+
+// metaValue is .Meta of an executed template. Templates refer to fields of Meta through its methods,
+// which report their use
+type metaValue struct {
+	meta Meta
+	used func()
+}
+
+func (m metaValue) Time() time.Time {
+	m.used()
+	return m.meta.Time
+}
+
+func (m metaValue) Version() string {
+	m.used()
+	return m.meta.Version
+}
+
+func (m metaValue) Workspace() string {
+	m.used()
+	return m.meta.Workspace
+}
+
+func (m metaValue) DataFile() string {
+	m.used()
+	return m.meta.DataFile
+}
+
+func (m metaValue) DataHash() string {
+	m.used()
+	return m.meta.DataHash
+}
+
+func (m metaValue) Commit() string {
+	m.used()
+	return m.meta.Commit
+}
+
+// String prints .Meta as Meta itself is printed
+func (m metaValue) String() string {
+	m.used()
+	return fmt.Sprint(m.meta)
+}
+
+// dateLayouts are layouts tried while converting strings to dates
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", DefaultDateLayout}
+
+// toTime converts dates, unix timestamps and strings in RFC 3339 or '2006-01-02 15:04:05' or '2006-01-02' format to time.Time
+func toTime(v interface{}) (time.Time, error) {
+	switch d := v.(type) {
+	case time.Time:
+		return d, nil
+	case *time.Time:
+		return *d, nil
+	case string:
+		s := strings.TrimSpace(d)
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Unix(sec, 0).UTC(), nil
+		}
+		return time.Time{}, fmt.Errorf("can't convert '%s' to a date", d)
+	}
+
+	sec, err := toInt(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("can't convert %v (%T) to a date", v, v)
+	}
+
+	return time.Unix(int64(sec), 0).UTC(), nil
+}
+
+// ParseTimestamp parses a timestamp given in RFC 3339 format or as a number of seconds since the Unix epoch
+func ParseTimestamp(s string) (time.Time, error) {
+	if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(sec, 0).UTC(), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp '%s', expected RFC 3339 format (e.g. 2019-03-08T12:00:00Z) or unix time", s)
+	}
+
+	return t, nil
+}
+
+// Date formats a date with a given layout, e.g. '2006-01-02 15:04'
+func Date(layout string, v interface{}) (string, error) {
+	t, err := toTime(v)
+	if err != nil {
+		return "", err
+	}
+
+	return t.Format(layout), nil
+}
+
+// DateInZone formats a date with a given layout in a given time zone, e.g. 'UTC' or 'Europe/Warsaw'
+func DateInZone(layout string, zone string, v interface{}) (string, error) {
+	t, err := toTime(v)
+	if err != nil {
+		return "", err
+	}
+
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return "", fmt.Errorf("unknown time zone: %s", zone)
+	}
+
+	return t.In(loc).Format(layout), nil
+}
+
+// ToDate parses a string with a given layout and returns a date
+func ToDate(layout string, s string) (time.Time, error) {
+	return time.Parse(layout, strings.TrimSpace(s))
+}
+
+// DateAdd returns a date shifted by a given duration, e.g. '-1h30m' or '90d' (days are allowed besides units of Go durations)
+func DateAdd(duration string, v interface{}) (time.Time, error) {
+	t, err := toTime(v)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if strings.HasSuffix(duration, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(duration, "d"))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid duration: %s", duration)
+		}
+		return t.AddDate(0, 0, days), nil
+	}
+
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid duration: %s", duration)
+	}
+
+	return t.Add(d), nil
+}
+
+// UnixEpoch returns a number of seconds since the Unix epoch of a date
+func UnixEpoch(v interface{}) (int64, error) {
+	t, err := toTime(v)
+	if err != nil {
+		return 0, err
+	}
+
+	return t.Unix(), nil
+}
@@ -0,0 +1,102 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDate(t *testing.T) {
+	var testCases = []struct {
+		layout   string
+		date     interface{}
+		expected string
+	}{
+		{"2006-01-02 15:04", time.Date(2019, 3, 8, 12, 30, 0, 0, time.UTC), "2019-03-08 12:30"},
+		{"02.01.2006", "2019-03-08", "08.03.2019"},
+		{"15:04:05", "2019-03-08 12:30:15", "12:30:15"},
+		{time.RFC3339, "2019-03-08T12:30:00+01:00", "2019-03-08T12:30:00+01:00"},
+		{time.RFC3339, 1552003200, "2019-03-08T00:00:00Z"},
+		{time.RFC3339, "1552003200", "2019-03-08T00:00:00Z"},
+	}
+
+	for _, tc := range testCases {
+		result, err := Date(tc.layout, tc.date)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if result != tc.expected {
+			t.Errorf("expected to get '%s', instead got '%s'", tc.expected, result)
+		}
+	}
+
+	if _, err := Date(time.RFC3339, "yesterday"); err == nil {
+		t.Error("expected to get an error for 'yesterday', instead got nil")
+	}
+}
+
+func TestDateAdd(t *testing.T) {
+	var testCases = []struct {
+		duration string
+		expected string
+	}{
+		{"90d", "2019-06-06T00:00:00Z"},
+		{"-1d", "2019-03-07T00:00:00Z"},
+		{"36h", "2019-03-09T12:00:00Z"},
+		{"-1h30m", "2019-03-07T22:30:00Z"},
+	}
+
+	for _, tc := range testCases {
+		result, err := DateAdd(tc.duration, "2019-03-08")
+		if err != nil {
+			t.Error(err)
+		}
+
+		if result.Format(time.RFC3339) != tc.expected {
+			t.Errorf("expected to get '%s', instead got '%s'", tc.expected, result.Format(time.RFC3339))
+		}
+	}
+
+	if _, err := DateAdd("week", "2019-03-08"); err == nil {
+		t.Error("expected to get an error for 'week', instead got nil")
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	var testCases = []struct {
+		timestamp string
+		expected  time.Time
+	}{
+		{"2019-03-08T12:00:00Z", time.Date(2019, 3, 8, 12, 0, 0, 0, time.UTC)},
+		{"1552046400", time.Date(2019, 3, 8, 12, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		result, err := ParseTimestamp(tc.timestamp)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if !result.Equal(tc.expected) {
+			t.Errorf("expected to get '%s', instead got '%s'", tc.expected, result)
+		}
+	}
+
+	if _, err := ParseTimestamp("08.03.2019"); err == nil {
+		t.Error("expected to get an error for '08.03.2019', instead got nil")
+	}
+}
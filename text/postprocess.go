@@ -0,0 +1,286 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Post-processing steps applied to the output of a template
+const (
+	// TrimTrailing strips trailing whitespaces from every line
+	TrimTrailing = "trim_trailing"
+	// CollapseBlank collapses consecutive blank lines into a single one
+	CollapseBlank = "collapse_blank"
+	// IndentIncludes indents every line of a block rendered with 'include' to the indentation of the include site
+	IndentIncludes = "indent_includes"
+)
+
+// includeStart and includeEnd mark the output of 'include' function, so it can be re-indented after execution
+const (
+	includeStart = '\uE000'
+	includeEnd   = '\uE001'
+)
+
+// unmarkedInclude is a name of 'include' function returning output without marks. It's added to a template
+// after parsing, so it can't be called directly from templates
+const unmarkedInclude = "include_unmarked"
+
+// CheckPostProcess checks if all given post-processing steps are known
+func CheckPostProcess(steps []string) error {
+	for _, step := range steps {
+		switch step {
+		case TrimTrailing, CollapseBlank, IndentIncludes:
+		default:
+			return fmt.Errorf("unknown post-processing step: %s", step)
+		}
+	}
+
+	return nil
+}
+
+// SetPostProcess sets post-processing steps applied to the output unless they are defined in template's front-matter
+func (t *Template) SetPostProcess(steps []string) {
+	t.postprocess = steps
+}
+
+// postProcessSteps returns post-processing steps used by a template (front-matter first, then these set for a template)
+func (t *Template) postProcessSteps() []string {
+	if t.FrontMatter.PostProcess != nil {
+		return t.FrontMatter.PostProcess
+	}
+
+	return t.postprocess
+}
+
+// PostProcess applies given post-processing steps to the output of a template and returns it.
+// Marks of included blocks are always removed, even if they are not re-indented
+func PostProcess(s string, steps []string) string {
+	enabled := make(map[string]bool)
+	for _, step := range steps {
+		enabled[step] = true
+	}
+
+	s = indentIncludes(s, enabled[IndentIncludes])
+
+	if enabled[TrimTrailing] {
+		s = trimTrailing(s)
+	}
+
+	if enabled[CollapseBlank] {
+		s = collapseBlank(s)
+	}
+
+	return s
+}
+
+// indentIncludes removes marks of included blocks and, if indent is true, prefixes every line of such block
+// with indentation of the line where 'include' was placed
+func indentIncludes(s string, indent bool) string {
+	if !strings.ContainsRune(s, includeStart) {
+		return s
+	}
+
+	var out strings.Builder
+	var stack []string
+	var lineStart int
+	var pending bool
+
+	for _, ch := range s {
+		switch {
+		case ch == includeStart:
+			line := out.String()[lineStart:]
+			stack = append(stack, line[:len(line)-len(strings.TrimLeft(line, " \t"))])
+		case ch == includeEnd:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			pending = false
+		case ch == '\n':
+			out.WriteRune(ch)
+			lineStart = out.Len()
+			pending = indent && len(stack) > 0
+		default:
+			// indentation is written lazily, so empty lines of included block stay empty
+			if pending && ch != '\r' {
+				out.WriteString(stack[len(stack)-1])
+				pending = false
+			}
+			out.WriteRune(ch)
+		}
+	}
+
+	return out.String()
+}
+
+// trimTrailing strips trailing spaces and tabs from every line
+func trimTrailing(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		cr := strings.HasSuffix(line, "\r")
+		lines[i] = strings.TrimRight(line, " \t\r")
+		if cr {
+			lines[i] += "\r"
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// collapseBlank replaces every run of blank lines with a single empty line
+func collapseBlank(s string) string {
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+
+	var blank bool
+	for i, line := range lines {
+		isBlank := strings.TrimSpace(line) == ""
+		// the last element is what follows the final newline, it is kept as is
+		if isBlank && blank && i != len(lines)-1 {
+			continue
+		}
+		if isBlank && i != len(lines)-1 {
+			line = strings.TrimLeft(line, " \t")
+		}
+		blank = isBlank
+		out = append(out, line)
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// Indent prefixes every non empty line of s with n spaces. Included blocks indented this way
+// are not re-indented again by post-processing
+func Indent(n int, s string) string {
+	if n < 0 {
+		n = 0
+	}
+	pad := strings.Repeat(" ", n)
+	s = indentIncludes(s, false)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = pad + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// NIndent works as Indent, but also prepends a new line to s
+func NIndent(n int, s string) string {
+	return "\n" + Indent(n, s)
+}
+
+// include is a placeholder of 'include' function used outside of a template's execution
+func include(name string, data interface{}) (string, error) {
+	return "", fmt.Errorf("include of %s is not available outside of a template", name)
+}
+
+// includeFunc returns 'include' function which executes a named template from tt and returns its marked output
+func includeFunc(tt *template.Template) func(string, interface{}) (string, error) {
+	return func(name string, data interface{}) (string, error) {
+		var out strings.Builder
+
+		err := tt.ExecuteTemplate(&out, name, data)
+		if err != nil {
+			return "", err
+		}
+
+		return string(includeStart) + out.String() + string(includeEnd), nil
+	}
+}
+
+// unmarkIncludes makes 'include' return output without marks everywhere but where it is printed directly
+// or passed to 'indent' and 'nindent', so marks never reach other functions or variables of a template
+func unmarkIncludes(tt *template.Template) {
+	include := includeFunc(tt)
+	tt.Funcs(template.FuncMap{unmarkedInclude: func(name string, data interface{}) (string, error) {
+		out, err := include(name, data)
+		return indentIncludes(out, false), err
+	}})
+
+	for _, tpl := range tt.Templates() {
+		if tpl.Tree != nil {
+			unmarkNode(tpl.Tree.Root)
+		}
+	}
+}
+
+// unmarkNode walks a parse tree and renames calls of 'include' whose output is used by anything else than
+// output of a template or indentation functions
+func unmarkNode(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			unmarkNode(child)
+		}
+	case *parse.ActionNode:
+		unmarkPipe(n.Pipe, len(n.Pipe.Decl) == 0)
+	case *parse.IfNode:
+		unmarkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		unmarkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		unmarkBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		unmarkPipe(n.Pipe, false)
+	case *parse.PipeNode:
+		unmarkPipe(n, false)
+	case *parse.ChainNode:
+		unmarkNode(n.Node)
+	}
+}
+
+func unmarkBranch(n *parse.BranchNode) {
+	unmarkPipe(n.Pipe, false)
+	unmarkNode(n.List)
+	unmarkNode(n.ElseList)
+}
+
+// unmarkPipe renames 'include' commands of a pipeline, printed tells if pipeline's result goes to the output
+func unmarkPipe(pipe *parse.PipeNode, printed bool) {
+	if pipe == nil {
+		return
+	}
+
+	for i, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			unmarkNode(arg)
+		}
+
+		ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+		if !ok || ident.Ident != "include" {
+			continue
+		}
+
+		keep := i == len(pipe.Cmds)-1 && printed
+		if i < len(pipe.Cmds)-1 {
+			if next, ok := pipe.Cmds[i+1].Args[0].(*parse.IdentifierNode); ok {
+				keep = next.Ident == "indent" || next.Ident == "nindent"
+			}
+		}
+		if !keep {
+			ident.Ident = unmarkedInclude
+		}
+	}
+}
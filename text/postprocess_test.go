@@ -0,0 +1,159 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostProcess(t *testing.T) {
+	var testCases = []struct {
+		input    string
+		steps    []string
+		expected string
+	}{
+		{"a  \nb\t\r\nc", []string{TrimTrailing}, "a\nb\r\nc"},
+		{"a\n\n  \n\nb\n\nc\n", []string{CollapseBlank}, "a\n\nb\n\nc\n"},
+		{"a  \n \n\nb", []string{TrimTrailing, CollapseBlank}, "a\n\nb"},
+		{"a\n  \uE000b\nc\n\nd\uE001\ne", nil, "a\n  b\nc\n\nd\ne"},
+		{"a\n  \uE000b\nc\n\nd\uE001\ne", []string{IndentIncludes}, "a\n  b\n  c\n\n  d\ne"},
+		{" \uE000a\n  \uE000b\nc\uE001\nd\n\uE001", []string{IndentIncludes}, " a\n   b\n   c\n d\n"},
+	}
+
+	for _, tc := range testCases {
+		result := PostProcess(tc.input, tc.steps)
+		if result != tc.expected {
+			t.Errorf("expected to get %q, instead got %q", tc.expected, result)
+		}
+	}
+}
+
+func TestIndent(t *testing.T) {
+	if result := Indent(2, "a\n\nb"); result != "  a\n\n  b" {
+		t.Errorf("expected to get indented string, instead got %q", result)
+	}
+
+	if result := NIndent(4, "a"); result != "\n    a" {
+		t.Errorf("expected to get indented string, instead got %q", result)
+	}
+}
+
+func TestExecutePostProcess(t *testing.T) {
+	content := `{{define "acl"}}permit 10.0.0.0/8
+permit 192.168.0.0/16
+{{end}}ip access-list standard MGMT
+  {{include "acl" .}}
+{{- "   "}}
+
+
+end
+`
+	tpl, err := NewTemplate(tplData, "test_template", strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl.SetPostProcess([]string{TrimTrailing, CollapseBlank, IndentIncludes})
+
+	w := &strings.Builder{}
+	err = tpl.Execute(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "ip access-list standard MGMT\n  permit 10.0.0.0/8\n  permit 192.168.0.0/16\n\nend\n"
+	if w.String() != expected {
+		t.Errorf("expected to get %q, instead got %q", expected, w.String())
+	}
+
+	tpl, err = NewTemplate(tplData, "test_template", strings.NewReader("{{/*---\npostprocess: []\n---*/}}\n"+content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl.SetPostProcess([]string{TrimTrailing, CollapseBlank, IndentIncludes})
+
+	w = &strings.Builder{}
+	err = tpl.Execute(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(w.String(), "  permit 192") {
+		t.Errorf("expected to get output without post-processing, instead got %q", w.String())
+	}
+}
+
+func TestExecuteNIndent(t *testing.T) {
+	content := "{{define \"acl\"}}permit any\ndeny any\n{{end}}ip access-list standard MGMT{{include \"acl\" . | nindent 2}}end\n"
+
+	tpl, err := NewTemplate(tplData, "test_template", strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl.SetPostProcess([]string{IndentIncludes})
+
+	w := &strings.Builder{}
+	err = tpl.Execute(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "ip access-list standard MGMT\n  permit any\n  deny any\nend\n"
+	if w.String() != expected {
+		t.Errorf("expected to get %q, instead got %q", expected, w.String())
+	}
+}
+
+func TestIncludeMarks(t *testing.T) {
+	var testCases = []struct {
+		content  string
+		expected string
+	}{
+		{"{{include \"x\" . | upper}}", "\"ABC\""},
+		{"{{len (include \"x\" .)}}", "5"},
+		{"{{$x := include \"x\" .}}{{printf \"%q\" $x}}", "\"\\\"abc\\\"\""},
+		{"{{if eq (include \"x\" .) \"\\\"abc\\\"\"}}equal{{end}}", "equal"},
+		{"{{include \"y\" . | len}}", "7"},
+		{"{{include \"x\" . | indent 2}}", "  \"abc\""},
+	}
+
+	for _, tc := range testCases {
+		content := "{{define \"x\"}}\"abc\"{{end}}{{define \"y\"}}[{{include \"x\" .}}]{{end}}" + tc.content
+
+		tpl, err := NewTemplate(tplData, "test_template", strings.NewReader(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := &strings.Builder{}
+		err = tpl.Execute(w)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if w.String() != tc.expected {
+			t.Errorf("expected to get %q for '%s', instead got %q", tc.expected, tc.content, w.String())
+		}
+	}
+
+	// function returning unmarked output is internal and can't be called by templates
+	tpl, err := NewTemplate(tplData, "test_template", strings.NewReader("{{define \"x\"}}abc{{end}}{{include_unmarked \"x\" .}}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = tpl.Execute(&strings.Builder{}); err == nil {
+		t.Error("expected to get an error for an undefined function, instead got nil")
+	}
+}
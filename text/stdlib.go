@@ -0,0 +1,582 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// toInt converts numbers and strings containing numbers to int
+func toInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int8, int16, int32, int64:
+		return int(reflect.ValueOf(n).Int()), nil
+	case uint, uint8, uint16, uint32, uint64:
+		return int(reflect.ValueOf(n).Uint()), nil
+	case float32, float64:
+		return int(reflect.ValueOf(n).Float()), nil
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil {
+			return 0, fmt.Errorf("can't convert '%s' to a number", n)
+		}
+		return i, nil
+	}
+
+	return 0, fmt.Errorf("can't convert %v (%T) to a number", v, v)
+}
+
+// toStrings converts slices (or a single value) to a slice of strings
+func toStrings(v interface{}) []string {
+	if v == nil {
+		return nil
+	}
+	if s, ok := v.([]string); ok {
+		return s
+	}
+
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return []string{fmt.Sprint(v)}
+	}
+
+	s := make([]string, val.Len())
+	for i := range s {
+		s[i] = fmt.Sprint(val.Index(i).Interface())
+	}
+
+	return s
+}
+
+// toList converts any slice to a slice of interface{}
+func toList(v interface{}) ([]interface{}, error) {
+	if l, ok := v.([]interface{}); ok {
+		return l, nil
+	}
+
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list, instead got %T", v)
+	}
+
+	l := make([]interface{}, val.Len())
+	for i := range l {
+		l[i] = val.Index(i).Interface()
+	}
+
+	return l, nil
+}
+
+// Upper returns s with all letters mapped to upper case
+func Upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+// Lower returns s with all letters mapped to lower case
+func Lower(s string) string {
+	return strings.ToLower(s)
+}
+
+// Title returns s with the first letter of every word mapped to upper case
+func Title(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		r, size := utf8.DecodeRuneInString(w)
+		words[i] = string(unicode.ToUpper(r)) + w[size:]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Trim removes leading and trailing whitespaces from s
+func Trim(s string) string {
+	return strings.TrimSpace(s)
+}
+
+// TrimAll removes leading and trailing characters contained in cutset from s
+func TrimAll(cutset string, s string) string {
+	return strings.Trim(s, cutset)
+}
+
+// TrimPrefix removes prefix from s
+func TrimPrefix(prefix string, s string) string {
+	return strings.TrimPrefix(s, prefix)
+}
+
+// TrimSuffix removes suffix from s
+func TrimSuffix(suffix string, s string) string {
+	return strings.TrimSuffix(s, suffix)
+}
+
+// Replace replaces all occurrences of old in s with new
+func Replace(old string, new string, s string) string {
+	return strings.Replace(s, old, new, -1)
+}
+
+// Contains reports whether substr is within s
+func Contains(substr string, s string) bool {
+	return strings.Contains(s, substr)
+}
+
+// HasPrefix reports whether s begins with prefix
+func HasPrefix(prefix string, s string) bool {
+	return strings.HasPrefix(s, prefix)
+}
+
+// HasSuffix reports whether s ends with suffix
+func HasSuffix(suffix string, s string) bool {
+	return strings.HasSuffix(s, suffix)
+}
+
+// Repeat returns s repeated n times
+func Repeat(n interface{}, s string) (string, error) {
+	count, err := toInt(n)
+	if err != nil {
+		return "", err
+	}
+	if count < 0 {
+		return "", fmt.Errorf("negative value of argument passed to repeat func not allowed")
+	}
+
+	return strings.Repeat(s, count), nil
+}
+
+// PadLeft pads s with spaces on the left up to a given width
+func PadLeft(width interface{}, s string) (string, error) {
+	w, err := toInt(width)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%*s", w, s), nil
+}
+
+// PadRight pads s with spaces on the right up to a given width
+func PadRight(width interface{}, s string) (string, error) {
+	w, err := toInt(width)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%-*s", w, s), nil
+}
+
+// RegexMatch reports whether s contains any match of the regular expression
+func RegexMatch(regex string, s string) (bool, error) {
+	re, err := regexp.Compile(regex)
+	if err != nil {
+		return false, err
+	}
+
+	return re.MatchString(s), nil
+}
+
+// RegexReplace replaces all matches of the regular expression in s with repl ($1 may be used for submatches)
+func RegexReplace(regex string, repl string, s string) (string, error) {
+	re, err := regexp.Compile(regex)
+	if err != nil {
+		return "", err
+	}
+
+	return re.ReplaceAllString(s, repl), nil
+}
+
+// Quote returns s in double quotes
+func Quote(s string) string {
+	return strconv.Quote(s)
+}
+
+// SplitList splits s with sep and returns all substrings
+func SplitList(sep string, s string) []string {
+	if s == "" {
+		return []string{}
+	}
+
+	return strings.Split(s, sep)
+}
+
+// Join joins all elements of a list with sep
+func Join(sep string, list interface{}) string {
+	return strings.Join(toStrings(list), sep)
+}
+
+// ToString returns string representation of v
+func ToString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+
+	return fmt.Sprint(v)
+}
+
+// Atoi converts v to a number
+func Atoi(v interface{}) (int, error) {
+	return toInt(v)
+}
+
+// Empty reports whether v is empty: nil, zero, false, empty string or empty collection
+func Empty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Map, reflect.String:
+		return val.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return val.IsNil()
+	}
+
+	return val.IsZero()
+}
+
+// Default returns v or def when v is empty
+func Default(def interface{}, v interface{}) interface{} {
+	if Empty(v) {
+		return def
+	}
+
+	return v
+}
+
+// Coalesce returns the first not empty value
+func Coalesce(v ...interface{}) interface{} {
+	for _, val := range v {
+		if !Empty(val) {
+			return val
+		}
+	}
+
+	return nil
+}
+
+// Ternary returns a when cond is true and b otherwise
+func Ternary(a interface{}, b interface{}, cond bool) interface{} {
+	if cond {
+		return a
+	}
+
+	return b
+}
+
+// Fail stops execution of a template with a given error message
+func Fail(msg string) (string, error) {
+	return "", fmt.Errorf("%s", msg)
+}
+
+// List returns a list of given values
+func List(v ...interface{}) []interface{} {
+	return v
+}
+
+// First returns the first element of a list
+func First(list interface{}) (interface{}, error) {
+	l, err := toList(list)
+	if err != nil || len(l) == 0 {
+		return nil, err
+	}
+
+	return l[0], nil
+}
+
+// Last returns the last element of a list
+func Last(list interface{}) (interface{}, error) {
+	l, err := toList(list)
+	if err != nil || len(l) == 0 {
+		return nil, err
+	}
+
+	return l[len(l)-1], nil
+}
+
+// Rest returns all elements of a list but the first one
+func Rest(list interface{}) ([]interface{}, error) {
+	l, err := toList(list)
+	if err != nil || len(l) == 0 {
+		return []interface{}{}, err
+	}
+
+	return l[1:], nil
+}
+
+// Append returns a new list with v appended to the list
+func Append(list interface{}, v interface{}) ([]interface{}, error) {
+	l, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]interface{}{}, l...), v), nil
+}
+
+// Has reports whether needle is an element of a list
+func Has(needle interface{}, list interface{}) (bool, error) {
+	l, err := toList(list)
+	if err != nil {
+		return false, err
+	}
+
+	for _, v := range l {
+		if reflect.DeepEqual(v, needle) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Uniq returns a list without repeated elements
+func Uniq(list interface{}) ([]interface{}, error) {
+	l, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	uniq := []interface{}{}
+	for _, v := range l {
+		if ok, _ := Has(v, uniq); !ok {
+			uniq = append(uniq, v)
+		}
+	}
+
+	return uniq, nil
+}
+
+// Reverse returns a list in reversed order
+func Reverse(list interface{}) ([]interface{}, error) {
+	l, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	rev := make([]interface{}, len(l))
+	for i, v := range l {
+		rev[len(l)-1-i] = v
+	}
+
+	return rev, nil
+}
+
+// SortAlpha returns elements of a list converted to strings and sorted alphabetically
+func SortAlpha(list interface{}) []string {
+	s := append([]string{}, toStrings(list)...)
+	sort.Strings(s)
+
+	return s
+}
+
+// Dict returns a dictionary of given key and value pairs
+func Dict(v ...interface{}) (map[string]interface{}, error) {
+	if len(v)%2 != 0 {
+		return nil, fmt.Errorf("dict func expects even number of arguments")
+	}
+
+	d := make(map[string]interface{}, len(v)/2)
+	for i := 0; i < len(v); i += 2 {
+		d[fmt.Sprint(v[i])] = v[i+1]
+	}
+
+	return d, nil
+}
+
+// Get returns a value of a given key in the dictionary or an empty string when there is no such key
+func Get(d interface{}, key string) interface{} {
+	val := reflect.ValueOf(d)
+	if val.Kind() != reflect.Map || val.Type().Key().Kind() != reflect.String {
+		return ""
+	}
+
+	v := val.MapIndex(reflect.ValueOf(key).Convert(val.Type().Key()))
+	if !v.IsValid() {
+		return ""
+	}
+
+	return v.Interface()
+}
+
+// HasKey reports whether the dictionary contains a given key
+func HasKey(d interface{}, key string) bool {
+	val := reflect.ValueOf(d)
+	if val.Kind() != reflect.Map || val.Type().Key().Kind() != reflect.String {
+		return false
+	}
+
+	return val.MapIndex(reflect.ValueOf(key).Convert(val.Type().Key())).IsValid()
+}
+
+// Keys returns sorted keys of the dictionary
+func Keys(d interface{}) []string {
+	val := reflect.ValueOf(d)
+	if val.Kind() != reflect.Map {
+		return []string{}
+	}
+
+	keys := make([]string, 0, val.Len())
+	for _, k := range val.MapKeys() {
+		keys = append(keys, fmt.Sprint(k.Interface()))
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// ints converts all given values to numbers
+func ints(v ...interface{}) ([]int, error) {
+	n := make([]int, len(v))
+	for i := range v {
+		var err error
+
+		n[i], err = toInt(v[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// Add returns sum of all given numbers
+func Add(v ...interface{}) (int, error) {
+	n, err := ints(v...)
+	if err != nil {
+		return 0, err
+	}
+
+	var sum int
+	for _, i := range n {
+		sum += i
+	}
+
+	return sum, nil
+}
+
+// Sub returns a minus b
+func Sub(a, b interface{}) (int, error) {
+	n, err := ints(a, b)
+	if err != nil {
+		return 0, err
+	}
+
+	return n[0] - n[1], nil
+}
+
+// Mul returns product of all given numbers
+func Mul(v ...interface{}) (int, error) {
+	n, err := ints(v...)
+	if err != nil {
+		return 0, err
+	}
+
+	product := 1
+	for _, i := range n {
+		product *= i
+	}
+
+	return product, nil
+}
+
+// Div returns a divided by b (integer division)
+func Div(a, b interface{}) (int, error) {
+	n, err := ints(a, b)
+	if err != nil {
+		return 0, err
+	}
+	if n[1] == 0 {
+		return 0, fmt.Errorf("division by zero")
+	}
+
+	return n[0] / n[1], nil
+}
+
+// Mod returns remainder of a divided by b
+func Mod(a, b interface{}) (int, error) {
+	n, err := ints(a, b)
+	if err != nil {
+		return 0, err
+	}
+	if n[1] == 0 {
+		return 0, fmt.Errorf("division by zero")
+	}
+
+	return n[0] % n[1], nil
+}
+
+// Max returns the biggest of given numbers
+func Max(a interface{}, v ...interface{}) (int, error) {
+	n, err := ints(append([]interface{}{a}, v...)...)
+	if err != nil {
+		return 0, err
+	}
+
+	max := n[0]
+	for _, i := range n[1:] {
+		if i > max {
+			max = i
+		}
+	}
+
+	return max, nil
+}
+
+// Min returns the smallest of given numbers
+func Min(a interface{}, v ...interface{}) (int, error) {
+	n, err := ints(append([]interface{}{a}, v...)...)
+	if err != nil {
+		return 0, err
+	}
+
+	min := n[0]
+	for _, i := range n[1:] {
+		if i < min {
+			min = i
+		}
+	}
+
+	return min, nil
+}
+
+// Seq returns a list of numbers from start to end (both inclusive)
+func Seq(start, end interface{}) ([]int, error) {
+	n, err := ints(start, end)
+	if err != nil {
+		return nil, err
+	}
+	if n[1]-n[0] > math.MaxUint16 {
+		return nil, fmt.Errorf("sequence from %d to %d is too long", n[0], n[1])
+	}
+
+	seq := []int{}
+	for i := n[0]; i <= n[1]; i++ {
+		seq = append(seq, i)
+	}
+
+	return seq, nil
+}
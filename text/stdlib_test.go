@@ -0,0 +1,104 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"strings"
+	"testing"
+)
+
+// exampleRows are rows of a dataset used by examples of lookup functions
+var exampleRows = []map[string]interface{}{
+	{"hostname": "r1", "loopback": "10.255.0.1", "site": "waw"},
+	{"hostname": "r2", "loopback": "10.255.0.2", "site": "waw"},
+	{"hostname": "r3", "loopback": "10.255.0.3", "site": "krk"},
+}
+
+func TestFuncDocs(t *testing.T) {
+	for _, doc := range FuncDocs() {
+		if _, ok := funcDocs[doc.Name]; !ok {
+			t.Errorf("expected to get description of '%s' func, instead got nothing", doc.Name)
+			continue
+		}
+
+		tpl, err := NewTemplate(map[string]string{}, doc.Name, strings.NewReader(doc.Example))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tpl.SetDataset(NewDataset(exampleRows))
+
+		w := &strings.Builder{}
+		err = tpl.Execute(w)
+		if err != nil {
+			t.Errorf("expected to execute example of '%s' func, instead got an error: %s", doc.Name, err)
+		}
+
+		if w.String() != doc.Result {
+			t.Errorf("expected to get '%s' from example of '%s' func, instead got '%s'", doc.Result, doc.Name, w.String())
+		}
+	}
+}
+
+func TestFuncDocsRegistered(t *testing.T) {
+	for name := range funcDocs {
+		if _, ok := templateFuncs[name]; !ok {
+			t.Errorf("expected to get '%s' func described in docs registered in templates, instead got nothing", name)
+		}
+	}
+}
+
+func TestStdlibErrors(t *testing.T) {
+	var testCases = []func() error{
+		func() error { _, err := Add(1, "one"); return err },
+		func() error { _, err := Div(1, 0); return err },
+		func() error { _, err := Mod("1", "0"); return err },
+		func() error { _, err := Repeat(-1, "a"); return err },
+		func() error { _, err := RegexReplace("(", "", "a"); return err },
+		func() error { _, err := Dict("a"); return err },
+		func() error { _, err := First("not a list"); return err },
+		func() error { _, err := Seq(0, 1000000); return err },
+		func() error { _, err := Fail("failed"); return err },
+	}
+
+	for i, tc := range testCases {
+		if tc() == nil {
+			t.Errorf("expected to get an error in test case %d, instead got nil", i)
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	var testCases = []struct {
+		value    interface{}
+		expected bool
+	}{
+		{nil, true},
+		{"", true},
+		{0, true},
+		{false, true},
+		{[]string{}, true},
+		{map[string]string{}, true},
+		{"a", false},
+		{1, false},
+		{true, false},
+		{[]int{0}, false},
+	}
+
+	for _, tc := range testCases {
+		if Empty(tc.value) != tc.expected {
+			t.Errorf("expected to get %t for %#v, instead got %t", tc.expected, tc.value, !tc.expected)
+		}
+	}
+}
@@ -15,24 +15,69 @@
 package text
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"text/template"
+	"text/template/parse"
 )
 
+// TemplateExt is an extension expected for all template files
+const TemplateExt = ".tpl"
+
+// reExtendsComment matches '{{/* extends "base" */}}' directive placed in the first line of a template
+var reExtendsComment = regexp.MustCompile(`^\s*\{\{-?\s*/\*\s*extends\s+"([^"]+)"\s*\*/\s*-?\}\}[ \t]*(\r?\n)?`)
+
+// TemplateLoader returns content of a template with a given name, it is used to load base templates
+type TemplateLoader func(name string) (io.Reader, error)
+
 // Template stores exactly one row and related to it template of a data read from CSV file
 type Template struct {
-	Data            map[string]string
+	Data            map[string]interface{}
 	TemplateName    string
 	TemplateContent string
-	missing         string
+	// FrontMatter holds settings of a template read from its front-matter block
+	FrontMatter FrontMatter
+	missing     string
+	delims      []string
+	postprocess []string
+	encoding    Encoding
+	dataset     *Dataset
+	usedDataset bool
+	usedMeta    bool
+	rows        []map[string]interface{}
+	meta        Meta
+	loader      TemplateLoader
+}
+
+// DirLoader returns TemplateLoader which reads templates from a given directory
+func DirLoader(dir string) TemplateLoader {
+	return func(name string) (io.Reader, error) {
+		if !strings.HasSuffix(name, TemplateExt) {
+			name = name + TemplateExt
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		return bytes.NewReader(b), nil
+	}
 }
 
 // NewTemplate creates and returns pointer to the Template
 func NewTemplate(data map[string]string, templateName string, templateReader io.Reader) (*Template, error) {
+	return NewTypedTemplate(stringRow(data), templateName, templateReader)
+}
+
+// NewTypedTemplate creates and returns pointer to the Template with a row of typed values (see CoerceRow)
+func NewTypedTemplate(data map[string]interface{}, templateName string, templateReader io.Reader) (*Template, error) {
 	t := &Template{}
 
 	t.Data = data
@@ -49,12 +94,27 @@ func NewTemplate(data map[string]string, templateName string, templateReader io.
 
 	b = normUTF8(b)
 
-	t.TemplateContent = string(b)
+	t.FrontMatter, t.TemplateContent, err = parseFrontMatter(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %s", templateName, err)
+	}
+
+	// '{{/* extends "name" */}}' directive may be used instead of 'extends' in front-matter
+	if m := reExtendsComment.FindStringSubmatch(t.TemplateContent); m != nil {
+		t.FrontMatter.Extends = m[1]
+		t.TemplateContent = t.TemplateContent[len(m[0]):]
+	}
+
 	t.missing = "invalid"
 
 	return t, nil
 }
 
+// SetLoader sets a loader used to read base templates when template extends another one
+func (t *Template) SetLoader(l TemplateLoader) {
+	t.loader = l
+}
+
 // SetGlobalVars sets additional variables to use while generating output from template
 func (t *Template) SetGlobalVars(m map[string]string) {
 	for k, v := range m {
@@ -62,20 +122,141 @@ func (t *Template) SetGlobalVars(m map[string]string) {
 	}
 }
 
+// SetRows turns a template into an aggregate one, which is executed once with all given rows available as .Rows
+// (next to global variables) instead of a single row
+func (t *Template) SetRows(rows []map[string]interface{}) {
+	t.rows = rows
+}
+
+// stringRow returns a row of string values as a row of typed values
+func stringRow(row map[string]string) map[string]interface{} {
+	if row == nil {
+		return nil
+	}
+
+	typed := make(map[string]interface{}, len(row))
+	for k, v := range row {
+		typed[k] = v
+	}
+
+	return typed
+}
+
+// context returns data a template is executed with: a row (or all rows as .Rows for aggregate templates),
+// global variables and .Meta. Fields missing in a row are set to empty strings (see zeroFields)
+func (t *Template) context(fields map[string]bool) interface{} {
+	ctx := make(map[string]interface{}, len(t.Data)+2)
+	for k, v := range t.Data {
+		ctx[k] = v
+	}
+	if t.rows != nil {
+		rows := t.rows
+		if len(fields) > 0 {
+			rows = make([]map[string]interface{}, len(t.rows))
+			for i, row := range t.rows {
+				rows[i] = fillFields(copyRow(row), fields)
+			}
+		}
+		ctx["Rows"] = rows
+	}
+	ctx[MetaKey] = metaValue{meta: t.meta, used: func() { t.usedMeta = true }}
+
+	return fillFields(ctx, fields)
+}
+
+// zeroFields returns names of all fields referred to by templates of tt. With missingkey=zero text/template
+// prints a missing value of a row (map of interface{}) as '<no value>', so such fields are added to rows
+// as empty strings, as they were when rows held only strings
+func zeroFields(tt *template.Template) map[string]bool {
+	fields := make(map[string]bool)
+
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				for _, arg := range cmd.Args {
+					walk(arg)
+				}
+			}
+		case *parse.FieldNode:
+			for _, ident := range n.Ident {
+				fields[ident] = true
+			}
+		case *parse.VariableNode:
+			for _, ident := range n.Ident[1:] {
+				fields[ident] = true
+			}
+		case *parse.ChainNode:
+			walk(n.Node)
+			for _, ident := range n.Field {
+				fields[ident] = true
+			}
+		}
+	}
+
+	for _, tpl := range tt.Templates() {
+		if tpl.Tree != nil {
+			walk(tpl.Tree.Root)
+		}
+	}
+
+	return fields
+}
+
+// fillFields sets fields missing in a row to empty strings and returns the row
+func fillFields(row map[string]interface{}, fields map[string]bool) map[string]interface{} {
+	for field := range fields {
+		if _, ok := row[field]; !ok {
+			row[field] = ""
+		}
+	}
+
+	return row
+}
+
 // Fprintt fills template with data and write the results to 'w'. It returns number of characters written and an error (if any)
 func Fprintt(w io.Writer, tplContent string, tplData map[string]string) (int, error) {
-	tt, err := template.New("").Funcs(templateFuncs).Parse(tplContent)
+	tt := template.New("").Funcs(templateFuncs)
+	tt, err := tt.Funcs(template.FuncMap{"include": includeFunc(tt)}).Parse(tplContent)
 	if err != nil {
 		return 0, err
 	}
+	unmarkIncludes(tt)
 
 	strWriter := &strings.Builder{}
-	tt.Execute(strWriter, tplData)
-
-	strReader := strings.NewReader(strWriter.String())
-	io.Copy(w, strReader)
+	err = tt.Execute(strWriter, tplData)
+	if err != nil {
+		return 0, err
+	}
 
-	return len(strWriter.String()), nil
+	return io.WriteString(w, PostProcess(strWriter.String(), nil))
 }
 
 // Sprintt fills template with data and returns it
@@ -107,12 +288,99 @@ func (t *Template) SetStrict(s string) {
 	}
 }
 
-// Execute executes template and outputs to 'w'
+// layouts returns chain of templates starting with the most basic layout and ending with the template itself
+func (t *Template) layouts() ([]*Template, error) {
+	chain := []*Template{t}
+	seen := map[string]bool{t.TemplateName: true}
+
+	for base := t.FrontMatter.Extends; base != ""; base = chain[0].FrontMatter.Extends {
+		if t.loader == nil {
+			return nil, fmt.Errorf("template %s extends %s, but no loader for base templates is set", t.TemplateName, base)
+		}
+		if seen[base] {
+			return nil, fmt.Errorf("template %s has cyclic inheritance on %s", t.TemplateName, base)
+		}
+		seen[base] = true
+
+		r, err := t.loader(base)
+		if err != nil {
+			return nil, err
+		}
+
+		bt, err := NewTypedTemplate(nil, base, r)
+		if err != nil {
+			return nil, err
+		}
+
+		chain = append([]*Template{bt}, chain...)
+	}
+
+	return chain, nil
+}
+
+// Bases returns names of base templates a template extends, starting with the most basic one
+func (t *Template) Bases() ([]string, error) {
+	chain, err := t.layouts()
+	if err != nil {
+		return nil, err
+	}
+
+	var bases []string
+	for _, tpl := range chain[:len(chain)-1] {
+		bases = append(bases, tpl.TemplateName)
+	}
+
+	return bases, nil
+}
+
+// Execute executes template, post-processes its output and writes it to 'w'. When template extends a base one,
+// base's layout is executed with blocks overridden by the ones defined in descendant templates
 func (t *Template) Execute(w io.Writer) error {
-	tt, err := template.New(t.TemplateName).Option("missingkey=" + t.missing).Funcs(templateFuncs).Parse(t.TemplateContent)
+	for _, column := range t.FrontMatter.Required {
+		if v, ok := t.Data[column]; !ok || v == nil || v == "" {
+			return fmt.Errorf("template %s requires column '%s' which is missing or empty", t.TemplateName, column)
+		}
+	}
+
+	chain, err := t.layouts()
 	if err != nil {
 		return err
 	}
 
-	return tt.Execute(w, t.Data)
+	missing := t.missing
+	if t.FrontMatter.MissingKey != "" {
+		missing = t.FrontMatter.MissingKey
+	}
+
+	var fields map[string]bool
+
+	tt := template.New(chain[0].TemplateName).Option("missingkey=" + missing).Funcs(templateFuncs)
+	t.usedDataset = false
+	t.usedMeta = false
+	tt.Funcs(template.FuncMap{"include": includeFunc(tt)}).Funcs(t.dataset.funcs(missing == "error", func(row map[string]interface{}) {
+		fillFields(row, fields)
+	}, func() { t.usedDataset = true }))
+
+	// every template in the chain is parsed with its own delimiters
+	for _, tpl := range chain {
+		_, err = tt.New(tpl.TemplateName).Delims(tpl.templateDelims(t.delims)).Parse(tpl.TemplateContent)
+		if err != nil {
+			return err
+		}
+	}
+
+	unmarkIncludes(tt)
+	if missing == "zero" {
+		fields = zeroFields(tt)
+	}
+
+	var out strings.Builder
+	err = tt.ExecuteTemplate(&out, chain[0].TemplateName, t.context(fields))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, PostProcess(out.String(), t.postProcessSteps()))
+
+	return err
 }
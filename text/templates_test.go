@@ -18,8 +18,10 @@ import (
 	"bytes"
 	"io"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 var tplContent = "example template with {{.Name}}\n"
@@ -110,4 +112,205 @@ func TestFprintt(t *testing.T) {
 	if strWriter.String() != referenceString {
 		t.Error("expected to get exactly the same string from template's output as the reference, instead it is different")
 	}
+
+	// length is counted after marks of included blocks are removed
+	strWriter.Reset()
+	n, err = Fprintt(strWriter, "{{define \"x\"}}abc{{end}}{{include \"x\" .}}", tplData)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 3 || strWriter.String() != "abc" {
+		t.Errorf("expected to get 'abc' of length 3, instead got %q of length %d", strWriter.String(), n)
+	}
+
+	_, err = Fprintt(strWriter, "{{fail \"x\"}}", tplData)
+	if err == nil {
+		t.Error("expected to get an error of template's execution, instead got nil")
+	}
+}
+
+func TestExecuteExtends(t *testing.T) {
+	layouts := map[string]string{
+		"base":     "hostname {{.Name}}\n{{block \"interfaces\" .}}no interfaces\n{{end}}{{block \"routing\" .}}no routing\n{{end}}",
+		"base_ios": "{{/* extends \"base\" */}}\n{{define \"routing\"}}router ospf 1\n{{end}}",
+	}
+	loader := func(name string) (io.Reader, error) {
+		content, ok := layouts[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return strings.NewReader(content), nil
+	}
+
+	var testCases = []struct {
+		content  string
+		expected string
+	}{
+		{
+			content:  "{{/* extends \"base\" */}}\n{{define \"interfaces\"}}interface Gi0/1\n{{end}}",
+			expected: "hostname *name*\ninterface Gi0/1\nno routing\n",
+		},
+		{
+			content:  "{{/*---\nextends: base_ios\n---*/}}\n{{define \"interfaces\"}}interface Gi0/2\n{{end}}",
+			expected: "hostname *name*\ninterface Gi0/2\nrouter ospf 1\n",
+		},
+		{
+			content:  "{{- /* extends \"base_ios\" */ -}}",
+			expected: "hostname *name*\nno interfaces\nrouter ospf 1\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tpl, err := NewTemplate(map[string]string{"Name": "*name*"}, "child", strings.NewReader(tc.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tpl.SetLoader(loader)
+
+		w := &strings.Builder{}
+		err = tpl.Execute(w)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if w.String() != tc.expected {
+			t.Errorf("expected to get '%s', instead got '%s'", tc.expected, w.String())
+		}
+	}
+}
+
+func TestBases(t *testing.T) {
+	layouts := map[string]string{
+		"base":     "hostname {{.Name}}",
+		"base_ios": "{{/* extends \"base\" */}}",
+	}
+	loader := func(name string) (io.Reader, error) {
+		return strings.NewReader(layouts[name]), nil
+	}
+
+	var testCases = []struct {
+		content  string
+		expected []string
+	}{
+		{"hostname {{.Name}}", nil},
+		{"{{/* extends \"base\" */}}", []string{"base"}},
+		{"{{/* extends \"base_ios\" */}}", []string{"base", "base_ios"}},
+	}
+
+	for _, tc := range testCases {
+		tpl, err := NewTemplate(tplData, "child", strings.NewReader(tc.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tpl.SetLoader(loader)
+
+		bases, err := tpl.Bases()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(bases, tc.expected) {
+			t.Errorf("expected to get bases %v, instead got %v", tc.expected, bases)
+		}
+	}
+}
+
+func TestExecuteExtendsErrors(t *testing.T) {
+	tpl, err := NewTemplate(tplData, "child", strings.NewReader("{{/* extends \"base\" */}}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = tpl.Execute(&strings.Builder{}); err == nil {
+		t.Error("expected to get an error when no loader is set, instead got nil")
+	}
+
+	tpl.SetLoader(func(name string) (io.Reader, error) {
+		return strings.NewReader("{{/* extends \"child\" */}}"), nil
+	})
+	if err = tpl.Execute(&strings.Builder{}); err == nil {
+		t.Error("expected to get an error on cyclic inheritance, instead got nil")
+	}
+}
+
+func TestExecuteAggregate(t *testing.T) {
+	content := "; {{.zone}}\n{{range .Rows}}{{.hostname}} IN A {{.loopback}}\n{{end}}; {{len .Rows}} records"
+
+	tpl, err := NewTemplate(map[string]string{}, "zone", strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl.SetGlobalVars(map[string]string{"zone": "acme.com"})
+	tpl.SetRows(exampleRows[:2])
+
+	w := &strings.Builder{}
+	err = tpl.Execute(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "; acme.com\nr1 IN A 10.255.0.1\nr2 IN A 10.255.0.2\n; 2 records"
+	if w.String() != expected {
+		t.Errorf("expected to get '%s', instead got '%s'", expected, w.String())
+	}
+}
+
+func TestExecuteMeta(t *testing.T) {
+	content := "! {{.Meta.Workspace}} {{.Meta.Version}} {{.Meta.Time | date \"2006-01-02 15:04\"}} {{.Name}}"
+
+	tpl, err := NewTemplate(map[string]string{"Name": "*name*", "Meta": "row"}, "test_template", strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl.SetMeta(Meta{Time: time.Date(2019, 3, 8, 12, 30, 0, 0, time.UTC), Version: "1.0.0", Workspace: "lab"})
+
+	w := &strings.Builder{}
+	err = tpl.Execute(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "! lab 1.0.0 2019-03-08 12:30 *name*"
+	if w.String() != expected {
+		t.Errorf("expected to get '%s', instead got '%s'", expected, w.String())
+	}
+}
+
+func TestExecuteMissingZero(t *testing.T) {
+	var testCases = []struct {
+		content  string
+		expected string
+	}{
+		{"[{{.Missing}}] [{{.Name}}]", "[] [*name*]"},
+		{"{{if .Missing}}set{{else}}unset{{end}}", "unset"},
+		{"literal <no value> [{{.Missing}}]", "literal <no value> []"},
+		{"{{range .Rows}}[{{.hostname}}{{.asn}}]{{end}}", "[r1][r2]"},
+		{"{{range where \"site\" \"waw\"}}[{{.hostname}}{{.asn}}]{{end}}", "[r1][r2]"},
+		{"{{with lookup_row \"hostname\" \"r9\"}}found{{else}}not found{{end}}", "not found"},
+		{"[{{(lookup_row \"hostname\" \"r1\").asn}}]", "[]"},
+	}
+
+	for _, tc := range testCases {
+		tpl, err := NewTemplate(tplData, "test_template", strings.NewReader(tc.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tpl.SetStrict("zero")
+		tpl.SetRows(exampleRows[:2])
+		tpl.SetDataset(NewDataset(exampleRows))
+
+		w := &strings.Builder{}
+		err = tpl.Execute(w)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if w.String() != tc.expected {
+			t.Errorf("expected to get '%s' for '%s', instead got '%s'", tc.expected, tc.content, w.String())
+		}
+	}
+
+	// rows given to a template are not modified
+	if _, ok := exampleRows[0]["asn"]; ok {
+		t.Errorf("expected to get rows without added fields, instead got %v", exampleRows[0])
+	}
 }
@@ -77,6 +77,18 @@ func Normalize(s string) string {
 	return nstr.String()
 }
 
+// ConvertLineEndings converts all line endings in s to a given style: 'lf' or 'crlf'. For any other style s is returned untouched
+func ConvertLineEndings(s string, style string) string {
+	switch style {
+	case "lf":
+		return strings.Replace(s, "\r\n", "\n", -1)
+	case "crlf":
+		return strings.Replace(strings.Replace(s, "\r\n", "\n", -1), "\n", "\r\n", -1)
+	}
+
+	return s
+}
+
 // ReadCSV reads from r and returns data arranged in slice of maps
 func ReadCSV(r io.Reader, comma rune) ([]map[string]string, error) {
 	m := make([]map[string]string, 0)
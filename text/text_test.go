@@ -131,3 +131,23 @@ func BenchmarkNormalize(b *testing.B) {
 		Normalize(str)
 	}
 }
+
+func TestConvertLineEndings(t *testing.T) {
+	var testCases = []struct {
+		input    string
+		style    string
+		expected string
+	}{
+		{"a\nb\r\nc", "lf", "a\nb\nc"},
+		{"a\nb\r\nc\n", "crlf", "a\r\nb\r\nc\r\n"},
+		{"a\nb\r\nc", "keep", "a\nb\r\nc"},
+		{"a\nb\r\nc", "", "a\nb\r\nc"},
+	}
+
+	for _, tc := range testCases {
+		result := ConvertLineEndings(tc.input, tc.style)
+		if result != tc.expected {
+			t.Errorf("expected to get %q, instead got %q", tc.expected, result)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of columns, values of CSV columns are strings unless other type is set for a column
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeFloat  = "float"
+	TypeBool   = "bool"
+	TypeList   = "list"
+	TypeIP4Net = "ipv4net"
+	TypeDate   = "date"
+)
+
+const (
+	// DefaultListSeparator separates elements of list columns unless other separator is given ('list:;')
+	DefaultListSeparator = ","
+	// DefaultDateLayout is a layout of date columns unless other layout is given ('date:02.01.2006')
+	DefaultDateLayout = "2006-01-02"
+)
+
+// IP4Net is a value of ipv4net column: IPv4 address with a prefix length, e.g. 10.0.0.1/24
+type IP4Net struct {
+	IP      string
+	Prefix  int
+	Network string
+	Mask    string
+}
+
+// String returns IPv4 address with a prefix length
+func (n IP4Net) String() string {
+	return n.IP + "/" + strconv.Itoa(n.Prefix)
+}
+
+// parseType splits type of a column into its name and an optional argument, e.g. 'list:;' into 'list' and ';'
+func parseType(typ string) (string, string) {
+	parts := strings.SplitN(typ, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
+
+// CheckColumnTypes checks if all types of columns are known
+func CheckColumnTypes(types map[string]string) error {
+	for column, typ := range types {
+		name, arg := parseType(typ)
+
+		switch name {
+		case TypeString, TypeInt, TypeFloat, TypeBool, TypeIP4Net:
+			if arg != "" {
+				return fmt.Errorf("type %s of column '%s' doesn't take an argument", name, column)
+			}
+		case TypeList, TypeDate:
+		default:
+			return fmt.Errorf("unknown type '%s' of column '%s', expected one of: %s", typ, column,
+				strings.Join([]string{TypeString, TypeInt, TypeFloat, TypeBool, TypeList, TypeIP4Net, TypeDate}, ", "))
+		}
+	}
+
+	return nil
+}
+
+// Coerce converts values of columns to given types and returns rows with native values: int, float64, bool, []string,
+// IP4Net or time.Time. Columns without a type stay strings. Empty values of int, float, bool and list columns become
+// zero values, empty values of ipv4net and date columns stay empty strings
+func Coerce(rows []map[string]string, types map[string]string) ([]map[string]interface{}, error) {
+	err := CheckColumnTypes(types)
+	if err != nil {
+		return nil, err
+	}
+
+	coerced := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		coerced[i] = make(map[string]interface{}, len(row))
+
+		for column, value := range row {
+			typ, ok := types[column]
+			if !ok {
+				coerced[i][column] = value
+				continue
+			}
+
+			coerced[i][column], err = coerceValue(typ, value)
+			if err != nil {
+				return nil, fmt.Errorf("row %d, column '%s': %s", i+1, column, err)
+			}
+		}
+	}
+
+	return coerced, nil
+}
+
+// coerceValue converts a value to a given type
+func coerceValue(typ string, value string) (interface{}, error) {
+	name, arg := parseType(typ)
+	value = strings.TrimSpace(value)
+
+	switch name {
+	case TypeInt:
+		if value == "" {
+			return 0, nil
+		}
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not an integer", value)
+		}
+		return i, nil
+	case TypeFloat:
+		if value == "" {
+			return 0.0, nil
+		}
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a number", value)
+		}
+		return f, nil
+	case TypeBool:
+		switch strings.ToLower(value) {
+		case "1", "t", "true", "y", "yes", "on":
+			return true, nil
+		case "", "0", "f", "false", "n", "no", "off":
+			return false, nil
+		}
+		return nil, fmt.Errorf("'%s' is not a boolean", value)
+	case TypeList:
+		if arg == "" {
+			arg = DefaultListSeparator
+		}
+		list := []string{}
+		for _, v := range strings.Split(value, arg) {
+			if v = strings.TrimSpace(v); v != "" {
+				list = append(list, v)
+			}
+		}
+		return list, nil
+	case TypeIP4Net:
+		if value == "" {
+			return "", nil
+		}
+		return parseIP4Net(value)
+	case TypeDate:
+		if value == "" {
+			return "", nil
+		}
+		if arg == "" {
+			arg = DefaultDateLayout
+		}
+		d, err := time.Parse(arg, value)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a date in %s format", value, arg)
+		}
+		return d, nil
+	}
+
+	return value, nil
+}
+
+// parseIP4Net parses IPv4 address with an optional prefix length (/32 by default)
+func parseIP4Net(value string) (IP4Net, error) {
+	if !strings.Contains(value, "/") {
+		value += "/32"
+	}
+
+	ip, ipnet, err := net.ParseCIDR(value)
+	if err != nil || ip.To4() == nil {
+		return IP4Net{}, fmt.Errorf("'%s' is not an IPv4 address", value)
+	}
+
+	prefix, _ := ipnet.Mask.Size()
+
+	return IP4Net{
+		IP:      ip.String(),
+		Prefix:  prefix,
+		Network: ipnet.IP.String(),
+		Mask:    net.IP(ipnet.Mask).String(),
+	}, nil
+}
@@ -0,0 +1,117 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package text
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCoerce(t *testing.T) {
+	types := map[string]string{
+		"ports":   "int",
+		"ratio":   "float",
+		"enabled": "bool",
+		"vlans":   "list",
+		"dns":     "list:;",
+		"lan":     "ipv4net",
+		"built":   "date",
+		"expires": "date:02.01.2006",
+	}
+
+	var testCases = []struct {
+		column   string
+		value    string
+		expected interface{}
+	}{
+		{"hostname", "r1", "r1"},
+		{"ports", " 48 ", 48},
+		{"ports", "", 0},
+		{"ratio", "0.75", 0.75},
+		{"enabled", "yes", true},
+		{"enabled", "FALSE", false},
+		{"enabled", "", false},
+		{"vlans", "10, 20,,30", []string{"10", "20", "30"}},
+		{"vlans", "", []string{}},
+		{"dns", "10.0.0.1;10.0.0.2", []string{"10.0.0.1", "10.0.0.2"}},
+		{"lan", "10.1.2.1/24", IP4Net{IP: "10.1.2.1", Prefix: 24, Network: "10.1.2.0", Mask: "255.255.255.0"}},
+		{"lan", "10.1.2.1", IP4Net{IP: "10.1.2.1", Prefix: 32, Network: "10.1.2.1", Mask: "255.255.255.255"}},
+		{"lan", "", ""},
+		{"built", "2019-03-08", time.Date(2019, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"expires", "31.12.2020", time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		rows, err := Coerce([]map[string]string{{tc.column: tc.value}}, types)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if result := rows[0][tc.column]; !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("expected to get %#v for '%s', instead got %#v", tc.expected, tc.value, result)
+		}
+	}
+}
+
+func TestCoerceErrors(t *testing.T) {
+	var testCases = []struct {
+		typ   string
+		value string
+	}{
+		{"int", "24a"},
+		{"float", "1,5"},
+		{"bool", "maybe"},
+		{"ipv4net", "10.0.0.256/24"},
+		{"ipv4net", "2001:db8::1/64"},
+		{"date", "08.03.2019"},
+		{"int:10", "1"},
+		{"integer", "1"},
+	}
+
+	for _, tc := range testCases {
+		if _, err := Coerce([]map[string]string{{"column": tc.value}}, map[string]string{"column": tc.typ}); err == nil {
+			t.Errorf("expected to get an error for '%s' of type %s, instead got nil", tc.value, tc.typ)
+		}
+	}
+}
+
+func TestExecuteTyped(t *testing.T) {
+	rows, err := Coerce([]map[string]string{{"ports": "48", "poe": "true", "lan": "10.1.2.1/24"}},
+		map[string]string{"ports": "int", "poe": "bool", "lan": "ipv4net"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := "{{if gt .ports 24}}big{{end}} {{if .poe}}poe{{end}} {{.lan}} {{.lan.Mask}} {{ip4 .lan 1}} {{ip4 .lan .ports}}"
+
+	tpl, err := NewTypedTemplate(rows[0], "typed", strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &strings.Builder{}
+	err = tpl.Execute(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "big poe 10.1.2.1/24 255.255.255.0 10.1.2.1 10.1.2.48"
+	if w.String() != expected {
+		t.Errorf("expected to get '%s', instead got '%s'", expected, w.String())
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ztp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TFTP opcodes (RFC 1350, RFC 2347)
+const (
+	opRRQ   = 1
+	opWRQ   = 2
+	opDATA  = 3
+	opACK   = 4
+	opERROR = 5
+	opOACK  = 6
+)
+
+// TFTP error codes
+const (
+	errNotDefined      = 0
+	errFileNotFound    = 1
+	errAccessViolation = 2
+)
+
+const (
+	// defaultBlockSize is a size of data blocks unless other size is negotiated with 'blksize' option (RFC 2348)
+	defaultBlockSize = 512
+	maxBlockSize     = 65464
+	// tftpTimeout is a time of waiting for an acknowledgement before a packet is sent again
+	tftpTimeout = time.Second
+	tftpRetries = 5
+)
+
+// ServeTFTP serves read requests of TFTP clients received on conn with files returned by a resolver until conn is closed.
+// Write requests are rejected. Options 'blksize' and 'tsize' are supported
+func ServeTFTP(conn net.PacketConn, resolve Resolver, log Logger) error {
+	buf := make([]byte, 65536)
+
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		packet := append([]byte{}, buf[:n]...)
+		go handleTFTP(conn, addr, packet, resolve, log)
+	}
+}
+
+// handleTFTP handles a single request, every transfer is served from its own port as required by RFC 1350
+func handleTFTP(conn net.PacketConn, addr net.Addr, packet []byte, resolve Resolver, log Logger) {
+	if len(packet) < 2 {
+		return
+	}
+
+	switch binary.BigEndian.Uint16(packet) {
+	case opRRQ:
+	case opWRQ:
+		conn.WriteTo(errorPacket(errAccessViolation, "write requests are not allowed"), addr)
+		return
+	default:
+		conn.WriteTo(errorPacket(errNotDefined, "unexpected packet"), addr)
+		return
+	}
+
+	fields := strings.Split(string(packet[2:]), "\x00")
+	if len(fields) < 2 {
+		conn.WriteTo(errorPacket(errNotDefined, "malformed request"), addr)
+		return
+	}
+	name, mode := fields[0], strings.ToLower(fields[1])
+
+	options := make(map[string]string)
+	for i := 2; i+1 < len(fields); i += 2 {
+		options[strings.ToLower(fields[i])] = fields[i+1]
+	}
+
+	content, _, err := resolve(strings.TrimPrefix(name, "/"))
+	if err != nil {
+		logf(log, "tftp %s %s: %s", addr, name, err)
+		code := errNotDefined
+		if err == ErrNotFound {
+			code = errFileNotFound
+		}
+		conn.WriteTo(errorPacket(code, err.Error()), addr)
+		return
+	}
+
+	if mode == "netascii" {
+		content = netascii(content)
+	}
+
+	transfer, err := net.ListenPacket("udp", transferAddr(conn.LocalAddr()))
+	if err != nil {
+		logf(log, "tftp %s %s: %s", addr, name, err)
+		return
+	}
+	defer transfer.Close()
+
+	err = sendFile(transfer, addr, content, options)
+	if err != nil {
+		logf(log, "tftp %s %s: %s", addr, name, err)
+		return
+	}
+
+	logf(log, "tftp %s %s: %d bytes", addr, name, len(content))
+}
+
+// transferAddr returns address of a port of a transfer: the same IP address as of the server and a random port
+func transferAddr(server net.Addr) string {
+	host, _, err := net.SplitHostPort(server.String())
+	if err != nil {
+		return ":0"
+	}
+
+	return net.JoinHostPort(host, "0")
+}
+
+// sendFile sends content in data blocks, every block is sent again until it is acknowledged
+func sendFile(conn net.PacketConn, addr net.Addr, content []byte, options map[string]string) error {
+	blockSize := defaultBlockSize
+
+	// accepted options are acknowledged with OACK, which is acknowledged by a client with ACK of block 0
+	accepted := make(map[string]string)
+	if v, ok := options["blksize"]; ok {
+		size, err := strconv.Atoi(v)
+		if err == nil && size >= 8 {
+			if size > maxBlockSize {
+				size = maxBlockSize
+			}
+			blockSize = size
+			accepted["blksize"] = strconv.Itoa(size)
+		}
+	}
+	if _, ok := options["tsize"]; ok {
+		accepted["tsize"] = strconv.Itoa(len(content))
+	}
+	if len(accepted) > 0 {
+		err := exchange(conn, addr, oackPacket(accepted), 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	for block := 1; ; block++ {
+		start := (block - 1) * blockSize
+		end := start + blockSize
+		if end > len(content) {
+			end = len(content)
+		}
+
+		data := make([]byte, 4, 4+end-start)
+		binary.BigEndian.PutUint16(data, opDATA)
+		binary.BigEndian.PutUint16(data[2:], uint16(block))
+		data = append(data, content[start:end]...)
+
+		err := exchange(conn, addr, data, uint16(block))
+		if err != nil {
+			return err
+		}
+
+		// the last block is shorter than a block size (it may be empty)
+		if end-start < blockSize {
+			return nil
+		}
+	}
+}
+
+// exchange sends a packet and waits for an acknowledgement of a given block
+func exchange(conn net.PacketConn, addr net.Addr, packet []byte, block uint16) error {
+	buf := make([]byte, 1024)
+
+	for retry := 0; retry < tftpRetries; retry++ {
+		_, err := conn.WriteTo(packet, addr)
+		if err != nil {
+			return err
+		}
+
+		deadline := time.Now().Add(tftpTimeout)
+		for {
+			conn.SetReadDeadline(deadline)
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				if e, ok := err.(net.Error); ok && e.Timeout() {
+					break
+				}
+				return err
+			}
+			if from.String() != addr.String() || n < 4 {
+				continue
+			}
+
+			switch binary.BigEndian.Uint16(buf) {
+			case opACK:
+				if binary.BigEndian.Uint16(buf[2:]) == block {
+					return nil
+				}
+			case opERROR:
+				return fmt.Errorf("transfer aborted by client: %s", strings.TrimRight(string(buf[4:n]), "\x00"))
+			}
+		}
+	}
+
+	return fmt.Errorf("no acknowledgement of block %d", block)
+}
+
+func errorPacket(code int, msg string) []byte {
+	packet := make([]byte, 4, 5+len(msg))
+	binary.BigEndian.PutUint16(packet, opERROR)
+	binary.BigEndian.PutUint16(packet[2:], uint16(code))
+	packet = append(packet, msg...)
+
+	return append(packet, 0)
+}
+
+func oackPacket(options map[string]string) []byte {
+	packet := make([]byte, 2)
+	binary.BigEndian.PutUint16(packet, opOACK)
+	for _, name := range []string{"blksize", "tsize"} {
+		if v, ok := options[name]; ok {
+			packet = append(packet, name+"\x00"+v+"\x00"...)
+		}
+	}
+
+	return packet
+}
+
+// netascii converts content to netascii: line feeds to CR LF and bare carriage returns to CR NUL
+func netascii(content []byte) []byte {
+	content = bytes.Replace(content, []byte("\r\n"), []byte("\n"), -1)
+	content = bytes.Replace(content, []byte("\r"), []byte("\r\x00"), -1)
+
+	return bytes.Replace(content, []byte("\n"), []byte("\r\n"), -1)
+}
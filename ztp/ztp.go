@@ -0,0 +1,70 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ztp serves generated files to devices booting with zero-touch provisioning over HTTP and TFTP
+package ztp
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by a Resolver when there is no file for a requested name
+var ErrNotFound = errors.New("file not found")
+
+// Resolver returns content of a file and its charset (utf-8 when empty) for a name requested by a device, e.g.
+// 'r1.cfg', 'FOC1234X0AB' or '0050.56aa.bbcc'
+type Resolver func(name string) (content []byte, charset string, err error)
+
+// Logger logs served requests
+type Logger func(format string, v ...interface{})
+
+// HTTPHandler returns a handler serving files returned by a resolver for paths of GET and HEAD requests
+func HTTPHandler(resolve Resolver, log Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		content, charset, err := resolve(name)
+		if err == ErrNotFound {
+			logf(log, "http %s %s: not found", r.RemoteAddr, name)
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			logf(log, "http %s %s: %s", r.RemoteAddr, name, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		logf(log, "http %s %s: %d bytes", r.RemoteAddr, name, len(content))
+		if charset == "" {
+			charset = "utf-8"
+		}
+		w.Header().Set("Content-Type", "text/plain; charset="+charset)
+		http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+	})
+}
+
+func logf(log Logger, format string, v ...interface{}) {
+	if log != nil {
+		log(format, v...)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright © 2019 Pawel Potrykus <pawel.potrykus@gmail.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ztp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var files = map[string][]byte{
+	"r1.cfg":     []byte("hostname r1\n"),
+	"big":        bytes.Repeat([]byte("0123456789abcdef"), 100),
+	"exact":      bytes.Repeat([]byte("x"), 1024),
+	"latin2.cfg": []byte("hostname r\xf3\n"),
+}
+
+// charsets holds charsets of files other than utf-8
+var charsets = map[string]string{
+	"latin2.cfg": "iso-8859-2",
+}
+
+func resolve(name string) ([]byte, string, error) {
+	if content, ok := files[name]; ok {
+		return content, charsets[name], nil
+	}
+
+	return nil, "", ErrNotFound
+}
+
+func TestHTTPHandler(t *testing.T) {
+	server := httptest.NewServer(HTTPHandler(resolve, nil))
+	defer server.Close()
+
+	var testCases = []struct {
+		path        string
+		status      int
+		expected    []byte
+		contentType string
+	}{
+		{"/r1.cfg", http.StatusOK, files["r1.cfg"], "text/plain; charset=utf-8"},
+		{"/big", http.StatusOK, files["big"], "text/plain; charset=utf-8"},
+		{"/latin2.cfg", http.StatusOK, files["latin2.cfg"], "text/plain; charset=iso-8859-2"},
+		{"/r2.cfg", http.StatusNotFound, nil, ""},
+	}
+
+	for _, tc := range testCases {
+		resp, err := http.Get(server.URL + tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != tc.status {
+			t.Errorf("expected to get status %d for %s, instead got %d", tc.status, tc.path, resp.StatusCode)
+		}
+		if tc.expected != nil && !bytes.Equal(body, tc.expected) {
+			t.Errorf("expected to get '%s' for %s, instead got '%s'", tc.expected, tc.path, body)
+		}
+		if contentType := resp.Header.Get("Content-Type"); tc.contentType != "" && contentType != tc.contentType {
+			t.Errorf("expected to get '%s' content type for %s, instead got '%s'", tc.contentType, tc.path, contentType)
+		}
+	}
+}
+
+// tftpGet downloads a file from TFTP server with given options
+func tftpGet(server string, name string, mode string, options ...string) ([]byte, error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	serverAddr, err := net.ResolveUDPAddr("udp", server)
+	if err != nil {
+		return nil, err
+	}
+
+	rrq := []byte{0, opRRQ}
+	rrq = append(rrq, name+"\x00"+mode+"\x00"...)
+	for _, o := range options {
+		rrq = append(rrq, o+"\x00"...)
+	}
+	if _, err = conn.WriteTo(rrq, serverAddr); err != nil {
+		return nil, err
+	}
+
+	blockSize := defaultBlockSize
+	var content []byte
+	buf := make([]byte, 65536)
+	for {
+		conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		ack := make([]byte, 4)
+		binary.BigEndian.PutUint16(ack, opACK)
+
+		switch binary.BigEndian.Uint16(buf) {
+		case opERROR:
+			return nil, fmt.Errorf("error %d: %s", binary.BigEndian.Uint16(buf[2:]), strings.TrimRight(string(buf[4:n]), "\x00"))
+		case opOACK:
+			fields := strings.Split(string(buf[2:n]), "\x00")
+			for i := 0; i+1 < len(fields); i += 2 {
+				if fields[i] == "blksize" {
+					fmt.Sscan(fields[i+1], &blockSize)
+				}
+			}
+			conn.WriteTo(ack, from)
+		case opDATA:
+			copy(ack[2:], buf[2:4])
+			content = append(content, buf[4:n]...)
+			conn.WriteTo(ack, from)
+			if n-4 < blockSize {
+				return content, nil
+			}
+		}
+	}
+}
+
+func TestServeTFTP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	go ServeTFTP(conn, resolve, nil)
+
+	var testCases = []struct {
+		name     string
+		mode     string
+		options  []string
+		expected []byte
+	}{
+		{"r1.cfg", "octet", nil, files["r1.cfg"]},
+		{"/r1.cfg", "netascii", nil, []byte("hostname r1\r\n")},
+		{"big", "octet", nil, files["big"]},
+		{"big", "octet", []string{"blksize", "1428", "tsize", "0"}, files["big"]},
+		{"exact", "octet", nil, files["exact"]},
+	}
+
+	for _, tc := range testCases {
+		content, err := tftpGet(conn.LocalAddr().String(), tc.name, tc.mode, tc.options...)
+		if err != nil {
+			t.Errorf("expected to get %s, instead got an error: %s", tc.name, err)
+			continue
+		}
+
+		if !bytes.Equal(content, tc.expected) {
+			t.Errorf("expected to get %d bytes of %s, instead got %d", len(tc.expected), tc.name, len(content))
+		}
+	}
+
+	_, err = tftpGet(conn.LocalAddr().String(), "r2.cfg", "octet")
+	if err == nil || !strings.HasPrefix(err.Error(), "error 1:") {
+		t.Errorf("expected to get 'file not found' error, instead got %v", err)
+	}
+}